@@ -2,8 +2,15 @@ package main
 
 import (
 	"log"
+	"os"
+	"strconv"
 	"workflow-engine/internal/api"
+	"workflow-engine/internal/engine"
 	"workflow-engine/internal/executor"
+	"workflow-engine/internal/runs"
+	"workflow-engine/internal/scheduler"
+	"workflow-engine/internal/store"
+	"workflow-engine/internal/types"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -13,6 +20,49 @@ func main() {
 	// 初始化执行器注册表
 	executor.InitExecutors()
 
+	// 全局最大并行节点数
+	if v := os.Getenv("WORKFLOW_MAX_PARALLEL"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal("Invalid WORKFLOW_MAX_PARALLEL:", err)
+		}
+		engine.SetMaxParallel(n)
+	}
+
+	// 打开工作流存储
+	dbPath := os.Getenv("WORKFLOW_DB_PATH")
+	if dbPath == "" {
+		dbPath = "workflow.db"
+	}
+	st, err := store.OpenBolt(dbPath)
+	if err != nil {
+		log.Fatal("Failed to open store:", err)
+	}
+	defer st.Close()
+
+	// 后台运行工作池
+	workers := 4
+	if v := os.Getenv("WORKFLOW_WORKERS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatal("Invalid WORKFLOW_WORKERS:", err)
+		}
+		workers = n
+	}
+	manager := runs.NewManager(st, workers, 100)
+
+	// 子工作流节点从存储中加载被调用的工作流
+	engine.SetWorkflowLoader(func(workflowID string, version int) (types.Workflow, int, error) {
+		spec, err := manager.StoredSpec(workflowID, version)
+		return spec.Workflow, spec.Version, err
+	})
+
+	// 启动定时调度器
+	sched := scheduler.New(st, manager)
+	if err := sched.Start(); err != nil {
+		log.Fatal("Failed to start scheduler:", err)
+	}
+
 	// 创建 Gin 引擎
 	r := gin.Default()
 
@@ -26,7 +76,7 @@ func main() {
 	}))
 
 	// 注册路由
-	api.RegisterRoutes(r)
+	api.RegisterRoutes(r, st, manager, sched)
 
 	// 启动服务
 	log.Println("Workflow Engine starting on :8080")
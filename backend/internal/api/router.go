@@ -1,11 +1,19 @@
 package api
 
 import (
+	"workflow-engine/internal/runs"
+	"workflow-engine/internal/scheduler"
+	"workflow-engine/internal/store"
+
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterRoutes 注册所有路由
-func RegisterRoutes(r *gin.Engine) {
+func RegisterRoutes(r *gin.Engine, st store.Store, manager *runs.Manager, sched *scheduler.Scheduler) {
+	workflowStore = st
+	runManager = manager
+	cronScheduler = sched
+
 	api := r.Group("/api")
 	{
 		// 健康检查
@@ -17,5 +25,42 @@ func RegisterRoutes(r *gin.Engine) {
 
 		// 工作流执行
 		api.POST("/workflow/execute", executeWorkflow)
+		api.POST("/workflow/validate", validateWorkflow)
+
+		// 工作流管理
+		api.GET("/workflows", listWorkflows)
+		api.POST("/workflows", createWorkflow)
+		api.GET("/workflows/:id", getWorkflow)
+		api.PUT("/workflows/:id", updateWorkflow)
+		api.DELETE("/workflows/:id", deleteWorkflow)
+		api.POST("/workflows/:id/execute", executeStoredWorkflow)
+
+		// 工作流版本
+		api.GET("/workflows/:id/versions", listWorkflowVersions)
+		api.GET("/workflows/:id/versions/:version", getWorkflowVersion)
+		api.POST("/workflows/:id/versions/:version/promote", promoteWorkflowVersion)
+		api.GET("/workflows/:id/diff", diffWorkflowVersions)
+
+		// 执行历史
+		api.GET("/runs", listRuns)
+		api.POST("/runs", submitRun)
+		api.GET("/runs/:id", getRun)
+		api.GET("/runs/:id/events", streamRunEvents)
+		api.POST("/runs/:id/cancel", cancelRun)
+
+		// 定时触发器
+		api.GET("/schedules", listSchedules)
+		api.POST("/schedules", createSchedule)
+		api.GET("/schedules/:id", getSchedule)
+		api.PUT("/schedules/:id", updateSchedule)
+		api.DELETE("/schedules/:id", deleteSchedule)
+
+		// webhook 触发器
+		api.GET("/webhooks", listWebhooks)
+		api.POST("/webhooks", createWebhook)
+		api.GET("/webhooks/:id", getWebhook)
+		api.PUT("/webhooks/:id", updateWebhook)
+		api.DELETE("/webhooks/:id", deleteWebhook)
+		api.POST("/hooks/:token", triggerWebhook)
 	}
 }
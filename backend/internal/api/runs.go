@@ -0,0 +1,263 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"workflow-engine/internal/runs"
+	"workflow-engine/internal/store"
+	"workflow-engine/internal/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runManager 运行管理器，由 RegisterRoutes 注入
+var runManager *runs.Manager
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// listRuns 查询运行记录
+// 支持按 workflowId、status、开始时间范围（from、to，RFC3339）过滤，按 page、pageSize 分页
+func listRuns(c *gin.Context) {
+	filter := store.RunFilter{
+		WorkflowID: c.Query("workflowId"),
+		Status:     c.Query("status"),
+	}
+
+	for param, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "时间格式错误（需为 RFC3339）: " + param,
+				})
+				return
+			}
+			*target = t
+		}
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "page 参数无效",
+		})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "pageSize 参数无效（1-" + strconv.Itoa(maxPageSize) + "）",
+		})
+		return
+	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+
+	runs, total, err := workflowStore.ListRuns(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "查询运行记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	summaries := make([]types.RunSummary, 0, len(runs))
+	for _, run := range runs {
+		summaries = append(summaries, run.Summary())
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"runs":     summaries,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// getRun 获取运行记录详情（含节点日志）
+func getRun(c *gin.Context) {
+	runID := c.Param("id")
+	run, err := workflowStore.GetRun(runID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "运行记录不存在: " + runID,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取运行记录失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// submitRun 提交异步运行，立即返回运行 ID
+func submitRun(c *gin.Context) {
+	var req types.SubmitRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	var spec runs.Spec
+	switch {
+	case req.WorkflowID != "":
+		var err error
+		spec, err = runManager.StoredSpec(req.WorkflowID, req.Version)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{
+					"error": "工作流或版本不存在: " + req.WorkflowID,
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "获取工作流失败: " + err.Error(),
+			})
+			return
+		}
+	case req.Workflow != nil:
+		spec = runs.Spec{Workflow: *req.Workflow}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请指定 workflowId 或 workflow",
+		})
+		return
+	}
+
+	spec.Inputs = req.Inputs
+	if !checkWorkflow(c, spec.Workflow) {
+		return
+	}
+
+	run, err := runManager.Submit(spec)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"runId":  run.ID,
+		"status": "queued",
+	})
+}
+
+// streamRunEvents 订阅运行事件（SSE）
+// 携带 Last-Event-ID 请求头（或 lastEventId 查询参数）时只回放该 ID 之后的事件，然后继续推送实时事件
+// 断开连接只会取消订阅，不影响运行本身
+func streamRunEvents(c *gin.Context) {
+	runID := c.Param("id")
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Streaming not supported"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Last-Event-ID 无效: " + lastEventID,
+			})
+			return
+		}
+		afterID = id
+	}
+
+	run, active := runManager.Get(runID)
+	if !active {
+		replayFinishedRun(c, flusher, runID, afterID)
+		return
+	}
+
+	setSSEHeaders(c)
+	history, events, unsubscribe := run.Subscribe(afterID)
+	defer unsubscribe()
+
+	for _, event := range history {
+		sendRunEvent(c, flusher, event)
+	}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			sendRunEvent(c, flusher, event)
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// replayFinishedRun 从执行历史回放已结束运行的事件
+// 事件流只在运行结束时保存，运行中服务重启的运行没有事件流
+// 已收到最后一个事件的重连返回 204，使 EventSource 停止重连
+func replayFinishedRun(c *gin.Context, flusher http.Flusher, runID string, afterID int64) {
+	record, err := workflowStore.GetRun(runID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "运行记录不存在: " + runID,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取运行记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	events, err := workflowStore.ListRunEvents(runID, afterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取运行事件失败: " + err.Error(),
+		})
+		return
+	}
+
+	if len(events) == 0 && afterID > 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	setSSEHeaders(c)
+	if len(events) == 0 {
+		// 没有保存事件流的运行（如服务重启前中断的运行）只返回运行记录
+		sendSSE(c, flusher, "complete", record)
+		return
+	}
+	for _, event := range events {
+		sendRunEvent(c, flusher, event)
+	}
+}
+
+// cancelRun 取消未结束的运行
+func cancelRun(c *gin.Context) {
+	runID := c.Param("id")
+	if !runManager.Cancel(runID) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "运行不存在或已结束: " + runID,
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"runId":  runID,
+		"status": "cancelling",
+	})
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"workflow-engine/internal/params"
+	"workflow-engine/internal/scheduler"
+	"workflow-engine/internal/store"
+	"workflow-engine/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// cronScheduler 定时调度器，由 RegisterRoutes 注入
+var cronScheduler *scheduler.Scheduler
+
+// listSchedules 列出所有定时触发器
+func listSchedules(c *gin.Context) {
+	schedules, err := workflowStore.ListSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取定时触发器列表失败: " + err.Error(),
+		})
+		return
+	}
+	now := time.Now()
+	for i := range schedules {
+		schedules[i].NextFireTime = scheduler.NextFireTime(schedules[i], now)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"schedules": schedules,
+	})
+}
+
+// getSchedule 获取定时触发器
+func getSchedule(c *gin.Context) {
+	schedule, ok := loadSchedule(c)
+	if !ok {
+		return
+	}
+	schedule.NextFireTime = scheduler.NextFireTime(schedule, time.Now())
+	c.JSON(http.StatusOK, schedule)
+}
+
+// createSchedule 创建定时触发器
+func createSchedule(c *gin.Context) {
+	var req types.SaveScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	schedule := types.Schedule{
+		ID:        uuid.New().String(),
+		CreatedAt: now,
+	}
+	saveSchedule(c, schedule, req, http.StatusCreated)
+}
+
+// updateSchedule 更新定时触发器
+func updateSchedule(c *gin.Context) {
+	var req types.SaveScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	schedule, ok := loadSchedule(c)
+	if !ok {
+		return
+	}
+	saveSchedule(c, schedule, req, http.StatusOK)
+}
+
+// deleteSchedule 删除定时触发器
+func deleteSchedule(c *gin.Context) {
+	id := c.Param("id")
+	if err := workflowStore.DeleteSchedule(id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "定时触发器不存在: " + id,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "删除定时触发器失败: " + err.Error(),
+		})
+		return
+	}
+	cronScheduler.Remove(id)
+	c.Status(http.StatusNoContent)
+}
+
+// saveSchedule 校验请求、保存定时触发器并重新加载调度
+func saveSchedule(c *gin.Context, schedule types.Schedule, req types.SaveScheduleRequest, status int) {
+	schedule.WorkflowID = req.WorkflowID
+	schedule.Version = req.Version
+	schedule.Cron = req.Cron
+	schedule.Timezone = req.Timezone
+	schedule.Overlap = req.Overlap
+	schedule.Inputs = req.Inputs
+	schedule.Enabled = req.Enabled == nil || *req.Enabled
+	schedule.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	if err := scheduler.Validate(schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "定时触发器配置错误: " + err.Error(),
+		})
+		return
+	}
+	spec, err := runManager.StoredSpec(schedule.WorkflowID, schedule.Version)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "工作流或版本不存在: " + schedule.WorkflowID + " v" + strconv.Itoa(schedule.Version),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取工作流失败: " + err.Error(),
+		})
+		return
+	}
+	if _, err := params.Resolve(spec.Workflow.Inputs, schedule.Inputs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "工作流输入错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := workflowStore.SaveSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "保存定时触发器失败: " + err.Error(),
+		})
+		return
+	}
+	if err := cronScheduler.Put(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "启动定时触发器失败: " + err.Error(),
+		})
+		return
+	}
+
+	schedule.NextFireTime = scheduler.NextFireTime(schedule, time.Now())
+	c.JSON(status, schedule)
+}
+
+// loadSchedule 读取路径参数 id 对应的定时触发器，失败时写入错误响应
+func loadSchedule(c *gin.Context) (types.Schedule, bool) {
+	id := c.Param("id")
+	schedule, err := workflowStore.GetSchedule(id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "定时触发器不存在: " + id,
+			})
+			return schedule, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取定时触发器失败: " + err.Error(),
+		})
+		return schedule, false
+	}
+	return schedule, true
+}
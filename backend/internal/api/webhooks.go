@@ -0,0 +1,303 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"workflow-engine/internal/store"
+	"workflow-engine/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// signatureHeader webhook 签名请求头，值为 sha256=<请求体 HMAC-SHA256 的十六进制>
+	signatureHeader = "X-Signature-256"
+	// maxHookBodySize webhook 请求体大小上限
+	maxHookBodySize = 1 << 20
+)
+
+// listWebhooks 列出所有 webhook 触发器
+func listWebhooks(c *gin.Context) {
+	webhooks, err := workflowStore.ListWebhooks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取 webhook 列表失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+	})
+}
+
+// getWebhook 获取 webhook 触发器
+func getWebhook(c *gin.Context) {
+	webhook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// createWebhook 创建 webhook 触发器，并生成随机的触发令牌
+func createWebhook(c *gin.Context) {
+	var req types.SaveWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	token, err := newHookToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "生成令牌失败: " + err.Error(),
+		})
+		return
+	}
+	webhook := types.Webhook{
+		ID:        uuid.New().String(),
+		Token:     token,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	saveWebhook(c, webhook, req, http.StatusCreated)
+}
+
+// updateWebhook 更新 webhook 触发器，令牌保持不变
+func updateWebhook(c *gin.Context) {
+	var req types.SaveWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	webhook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	saveWebhook(c, webhook, req, http.StatusOK)
+}
+
+// deleteWebhook 删除 webhook 触发器
+func deleteWebhook(c *gin.Context) {
+	id := c.Param("id")
+	if err := workflowStore.DeleteWebhook(id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "webhook 不存在: " + id,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "删除 webhook 失败: " + err.Error(),
+		})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// triggerWebhook 处理入站 webhook 请求并启动工作流
+// 请求体、请求头和查询参数作为 $trigger 注入起始节点；按 webhook 配置同步返回最终输出或异步返回运行 ID
+func triggerWebhook(c *gin.Context) {
+	webhook, err := workflowStore.GetWebhookByToken(c.Param("token"))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取 webhook 失败: " + err.Error(),
+		})
+		return
+	}
+	// 停用的 webhook 与不存在的相同，不暴露令牌是否有效
+	if err != nil || !webhook.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "webhook 不存在",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxHookBodySize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "读取请求体失败: " + err.Error(),
+		})
+		return
+	}
+
+	if webhook.Secret != "" && !validSignature(webhook.Secret, body, c.GetHeader(signatureHeader)) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "签名校验失败",
+		})
+		return
+	}
+
+	spec, err := runManager.StoredSpec(webhook.WorkflowID, webhook.Version)
+	if err != nil {
+		// webhook 存在但绑定的工作流或版本已被删除
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusGone, gin.H{
+				"error": "工作流或版本不存在: " + webhook.WorkflowID + " v" + strconv.Itoa(webhook.Version),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取工作流失败: " + err.Error(),
+		})
+		return
+	}
+	spec.Trigger = hookPayload(c, body)
+	if inputs, ok := spec.Trigger["body"].(map[string]interface{}); ok {
+		spec.Inputs = inputs
+	}
+
+	if webhook.Response == "sync" {
+		result := runManager.Execute(c.Request.Context(), spec, nil)
+		// 部分节点按 onError 策略失败时运行仍视为完成
+		status := http.StatusOK
+		if result.Status != "success" && result.Status != "partial" {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{
+			"runId":  result.RunID,
+			"status": result.Status,
+			"output": result.FinalOutput,
+			"error":  result.Error,
+		})
+		return
+	}
+
+	run, err := runManager.Submit(spec)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"runId":  run.ID,
+		"status": "queued",
+	})
+}
+
+// saveWebhook 校验请求并保存 webhook 触发器
+func saveWebhook(c *gin.Context, webhook types.Webhook, req types.SaveWebhookRequest, status int) {
+	switch req.Response {
+	case "", "async", "sync":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "响应方式无效: " + req.Response + "（可选 async、sync）",
+		})
+		return
+	}
+	if _, err := runManager.StoredSpec(req.WorkflowID, req.Version); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "工作流或版本不存在: " + req.WorkflowID + " v" + strconv.Itoa(req.Version),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取工作流失败: " + err.Error(),
+		})
+		return
+	}
+
+	webhook.WorkflowID = req.WorkflowID
+	webhook.Version = req.Version
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+	webhook.HasSecret = webhook.Secret != ""
+	webhook.Response = req.Response
+	webhook.Enabled = req.Enabled == nil || *req.Enabled
+	webhook.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	if err := workflowStore.SaveWebhook(webhook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "保存 webhook 失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(status, webhook)
+}
+
+// loadWebhook 读取路径参数 id 对应的 webhook 触发器，失败时写入错误响应
+func loadWebhook(c *gin.Context) (types.Webhook, bool) {
+	id := c.Param("id")
+	webhook, err := workflowStore.GetWebhook(id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "webhook 不存在: " + id,
+			})
+			return webhook, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取 webhook 失败: " + err.Error(),
+		})
+		return webhook, false
+	}
+	return webhook, true
+}
+
+// hookPayload 将 webhook 请求转换为触发数据
+// JSON 请求体解析为对象，其他内容按字符串传递；请求头和查询参数只取第一个值
+func hookPayload(c *gin.Context, body []byte) types.TaskInput {
+	var parsed interface{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			parsed = string(body)
+		}
+	}
+
+	headers := make(map[string]interface{}, len(c.Request.Header))
+	for key, values := range c.Request.Header {
+		if len(values) > 0 {
+			headers[key] = values[0]
+		}
+	}
+	query := make(map[string]interface{})
+	for key, values := range c.Request.URL.Query() {
+		if len(values) > 0 {
+			query[key] = values[0]
+		}
+	}
+
+	return types.TaskInput{
+		"body":    parsed,
+		"headers": headers,
+		"query":   query,
+	}
+}
+
+// validSignature 校验请求体的 HMAC-SHA256 签名，sha256= 前缀可省略
+func validSignature(secret string, body []byte, signature string) bool {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	expected, err := hex.DecodeString(signature)
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// newHookToken 生成 webhook 触发令牌
+func newHookToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
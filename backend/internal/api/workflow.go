@@ -3,9 +3,10 @@ package api
 import (
 	"encoding/json"
 	"net/http"
-	"time"
-	"workflow-engine/internal/executor"
+	"strconv"
+	"workflow-engine/internal/runs"
 	"workflow-engine/internal/types"
+	"workflow-engine/internal/validate"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,231 +21,82 @@ func executeWorkflow(c *gin.Context) {
 		return
 	}
 
-	// 设置 SSE 响应头
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	streamWorkflow(c, runs.Spec{Workflow: req.Workflow, Inputs: req.Inputs})
+}
 
-	// 确保可以 flush
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Streaming not supported"})
+// validateWorkflow 校验工作流定义，返回按节点、参数、边列出的问题
+func validateWorkflow(c *gin.Context) {
+	var req types.ValidateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
 		return
 	}
 
-	// 执行工作流并流式返回结果
-	runWorkflowStream(c, flusher, req.Workflow)
+	c.JSON(http.StatusOK, validate.Result(validate.Workflow(req.Workflow, false)))
 }
 
-// sendSSE 发送 SSE 事件
-func sendSSE(c *gin.Context, flusher http.Flusher, event string, data interface{}) {
-	jsonData, _ := json.Marshal(data)
-	c.Writer.Write([]byte("event: " + event + "\n"))
-	c.Writer.Write([]byte("data: " + string(jsonData) + "\n\n"))
-	flusher.Flush()
+// checkWorkflow 执行前校验工作流，未通过时写入 400 响应并返回 false
+func checkWorkflow(c *gin.Context, workflow types.Workflow) bool {
+	problems := validate.Workflow(workflow, false)
+	if len(problems) == 0 {
+		return true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "工作流校验失败: " + validate.Summary(problems),
+		"errors": problems,
+	})
+	return false
 }
 
-// runWorkflowStream 流式执行工作流
-func runWorkflowStream(c *gin.Context, flusher http.Flusher, workflow types.Workflow) {
-	startTime := time.Now()
-	logs := []types.NodeExecutionLog{}
-	nodeOutputs := make(map[string]types.TaskOutput)
-
-	// 拓扑排序获取执行顺序
-	executionOrder := topologicalSort(workflow.Nodes, workflow.Edges)
-
-	if len(executionOrder) != len(workflow.Nodes) {
-		result := types.WorkflowExecutionResult{
-			Status:    "error",
-			StartTime: startTime.Format(time.RFC3339),
-			EndTime:   time.Now().Format(time.RFC3339),
-			Logs:      logs,
-			Error:     "工作流存在循环依赖，无法执行",
-		}
-		sendSSE(c, flusher, "complete", result)
+// streamWorkflow 设置 SSE 响应并执行工作流
+func streamWorkflow(c *gin.Context, spec runs.Spec) {
+	if !checkWorkflow(c, spec.Workflow) {
 		return
 	}
 
-	// 构建节点映射
-	nodeMap := make(map[string]types.WorkflowNode)
-	for _, node := range workflow.Nodes {
-		nodeMap[node.ID] = node
+	// 确保可以 flush
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Streaming not supported"})
+		return
 	}
 
-	var finalOutput *types.TaskOutput
-
-	// 按顺序执行节点
-	for _, nodeID := range executionOrder {
-		node := nodeMap[nodeID]
-		nodeStartTime := time.Now()
-
-		// 发送节点开始执行事件
-		startLog := types.NodeExecutionLog{
-			NodeID:    nodeID,
-			NodeName:  node.Label,
-			Status:    "running",
-			Message:   "开始执行任务: " + node.Label,
-			Timestamp: nodeStartTime.Format(time.RFC3339),
-		}
-		logs = append(logs, startLog)
-		sendSSE(c, flusher, "node_start", startLog)
-
-		// 准备输入
-		input := prepareInput(nodeID, node.Config, workflow.Edges, nodeOutputs)
-
-		// 执行任务
-		output := executor.Execute(node.Type, input)
-		endTime := time.Now()
-		duration := endTime.Sub(nodeStartTime).Milliseconds()
-
-		// 保存输出
-		nodeOutputs[nodeID] = output
-
-		// 检查结果
-		if !output.IsSuccess() {
-			errorLog := types.NodeExecutionLog{
-				NodeID:    nodeID,
-				NodeName:  node.Label,
-				Status:    "error",
-				Message:   "任务执行失败: " + output.Error,
-				Input:     input,
-				Output:    &output,
-				Duration:  duration,
-				Timestamp: endTime.Format(time.RFC3339),
-			}
-			logs = append(logs, errorLog)
-			sendSSE(c, flusher, "node_complete", errorLog)
+	setSSEHeaders(c)
 
-			result := types.WorkflowExecutionResult{
-				Status:      "error",
-				StartTime:   startTime.Format(time.RFC3339),
-				EndTime:     endTime.Format(time.RFC3339),
-				Logs:        logs,
-				FinalOutput: &output,
-				Error:       "任务 \"" + node.Label + "\" 执行失败: " + output.Error,
-			}
-			sendSSE(c, flusher, "complete", result)
-			return
-		}
-
-		// 执行成功
-		successLog := types.NodeExecutionLog{
-			NodeID:    nodeID,
-			NodeName:  node.Label,
-			Status:    "success",
-			Message:   "任务执行成功: " + node.Label,
-			Input:     input,
-			Output:    &output,
-			Duration:  duration,
-			Timestamp: endTime.Format(time.RFC3339),
-		}
-		logs = append(logs, successLog)
-		sendSSE(c, flusher, "node_complete", successLog)
-
-		finalOutput = &output
-	}
-
-	result := types.WorkflowExecutionResult{
-		Status:      "success",
-		StartTime:   startTime.Format(time.RFC3339),
-		EndTime:     time.Now().Format(time.RFC3339),
-		Logs:        logs,
-		FinalOutput: finalOutput,
-	}
-	sendSSE(c, flusher, "complete", result)
+	// 执行工作流并流式返回结果
+	runWorkflowStream(c, flusher, spec)
 }
 
-// prepareInput 准备节点输入
-func prepareInput(nodeID string, config types.TaskInput, edges []types.WorkflowEdge, nodeOutputs map[string]types.TaskOutput) types.TaskInput {
-	input := make(types.TaskInput)
-
-	// 复制节点配置
-	for k, v := range config {
-		input[k] = v
-	}
-
-	// 获取前置节点的输出
-	predecessors := getPredecessors(nodeID, edges)
-	if len(predecessors) > 0 {
-		previous := make(map[string]interface{})
-		for _, predID := range predecessors {
-			if output, ok := nodeOutputs[predID]; ok {
-				previous[predID] = map[string]interface{}{
-					"error": output.Error,
-					"data":  output.Data,
-				}
-			}
-		}
-		input["$previous"] = previous
-
-		// 如果只有一个前置节点，展开其 data
-		if len(predecessors) == 1 {
-			if output, ok := nodeOutputs[predecessors[0]]; ok {
-				if data, ok := output.Data.(map[string]interface{}); ok {
-					for k, v := range data {
-						if _, exists := input[k]; !exists {
-							input[k] = v
-						}
-					}
-				}
-			}
-		}
-	}
-
-	return input
+// setSSEHeaders 设置 SSE 响应头
+func setSSEHeaders(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
 }
 
-// getPredecessors 获取前置节点
-func getPredecessors(nodeID string, edges []types.WorkflowEdge) []string {
-	var result []string
-	for _, edge := range edges {
-		if edge.Target == nodeID {
-			result = append(result, edge.Source)
-		}
-	}
-	return result
+// sendRunEvent 发送带 id 的运行事件，客户端重连时可通过 Last-Event-ID 续传
+func sendRunEvent(c *gin.Context, flusher http.Flusher, event types.RunEvent) {
+	c.Writer.Write([]byte("id: " + strconv.FormatInt(event.ID, 10) + "\n"))
+	sendSSE(c, flusher, event.Event, event.Data)
 }
 
-// topologicalSort 拓扑排序
-func topologicalSort(nodes []types.WorkflowNode, edges []types.WorkflowEdge) []string {
-	graph := make(map[string][]string)
-	inDegree := make(map[string]int)
-
-	// 初始化
-	for _, node := range nodes {
-		graph[node.ID] = []string{}
-		inDegree[node.ID] = 0
-	}
-
-	// 构建图
-	for _, edge := range edges {
-		graph[edge.Source] = append(graph[edge.Source], edge.Target)
-		inDegree[edge.Target]++
-	}
-
-	// BFS
-	var queue []string
-	for nodeID, degree := range inDegree {
-		if degree == 0 {
-			queue = append(queue, nodeID)
-		}
-	}
-
-	var result []string
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-		result = append(result, current)
-
-		for _, neighbor := range graph[current] {
-			inDegree[neighbor]--
-			if inDegree[neighbor] == 0 {
-				queue = append(queue, neighbor)
-			}
-		}
-	}
+// sendSSE 发送 SSE 事件
+func sendSSE(c *gin.Context, flusher http.Flusher, event string, data interface{}) {
+	jsonData, _ := json.Marshal(data)
+	c.Writer.Write([]byte("event: " + event + "\n"))
+	c.Writer.Write([]byte("data: " + string(jsonData) + "\n\n"))
+	flusher.Flush()
+}
 
-	return result
+// runWorkflowStream 流式执行工作流
+// 运行同样登记在运行管理器中，其他客户端可通过 /api/runs/:id/events 订阅
+func runWorkflowStream(c *gin.Context, flusher http.Flusher, spec runs.Spec) {
+	// 客户端断开连接时请求上下文被取消，引擎随之中止执行
+	runManager.Execute(c.Request.Context(), spec, func(event types.RunEvent) {
+		sendRunEvent(c, flusher, event)
+	})
 }
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"workflow-engine/internal/diff"
+	"workflow-engine/internal/runs"
+	"workflow-engine/internal/store"
+	"workflow-engine/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// workflowStore 工作流存储，由 RegisterRoutes 注入
+var workflowStore store.Store
+
+// listWorkflows 列出所有已保存的工作流
+func listWorkflows(c *gin.Context) {
+	defs, err := workflowStore.ListWorkflows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取工作流列表失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"workflows": defs,
+	})
+}
+
+// getWorkflow 获取工作流
+func getWorkflow(c *gin.Context) {
+	def, ok := loadWorkflow(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, def)
+}
+
+// createWorkflow 创建工作流
+func createWorkflow(c *gin.Context) {
+	var req types.SaveWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	def := types.WorkflowDefinition{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		Workflow:    req.Workflow,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	def, err := workflowStore.SaveWorkflow(def, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "保存工作流失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, def)
+}
+
+// updateWorkflow 更新工作流
+func updateWorkflow(c *gin.Context) {
+	var req types.SaveWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	def, ok := loadWorkflow(c)
+	if !ok {
+		return
+	}
+
+	def.Name = req.Name
+	def.Description = req.Description
+	def.Workflow = req.Workflow
+	def.UpdatedAt = time.Now().Format(time.RFC3339)
+	def, err := workflowStore.SaveWorkflow(def, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "保存工作流失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, def)
+}
+
+// deleteWorkflow 删除工作流
+func deleteWorkflow(c *gin.Context) {
+	id := c.Param("id")
+	if err := workflowStore.DeleteWorkflow(id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "工作流不存在: " + id,
+			})
+			return
+		}
+		if errors.Is(err, store.ErrInUse) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "删除工作流失败: " + err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "删除工作流失败: " + err.Error(),
+		})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// executeStoredWorkflow 按 ID 执行已保存的工作流（流式返回）
+// 可通过查询参数 version 执行指定的历史版本，默认执行当前版本；请求体可携带工作流输入
+func executeStoredWorkflow(c *gin.Context) {
+	var req types.ExecuteStoredWorkflowRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	def, ok := loadWorkflow(c)
+	if !ok {
+		return
+	}
+
+	workflow, version := def.Workflow, def.Version
+	if c.Query("version") != "" {
+		v, ok := loadVersion(c, c.Query("version"))
+		if !ok {
+			return
+		}
+		workflow, version = v.Workflow, v.Version
+	}
+
+	streamWorkflow(c, runs.Spec{Workflow: workflow, WorkflowID: def.ID, Version: version, Inputs: req.Inputs})
+}
+
+// listWorkflowVersions 列出工作流的所有版本
+func listWorkflowVersions(c *gin.Context) {
+	id := c.Param("id")
+	versions, err := workflowStore.ListVersions(id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "工作流不存在: " + id,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取版本列表失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"versions": versions,
+	})
+}
+
+// getWorkflowVersion 获取工作流的指定版本
+func getWorkflowVersion(c *gin.Context) {
+	version, ok := loadVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
+
+// promoteWorkflowVersion 将历史版本回滚为当前版本
+// 回滚不会修改历史，而是以该版本的内容创建一个新版本
+func promoteWorkflowVersion(c *gin.Context) {
+	def, ok := loadWorkflow(c)
+	if !ok {
+		return
+	}
+	version, ok := loadVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	def.Name = version.Name
+	def.Description = version.Description
+	def.Workflow = version.Workflow
+	def.UpdatedAt = time.Now().Format(time.RFC3339)
+	def, err := workflowStore.SaveWorkflow(def, version.Version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "回滚工作流失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, def)
+}
+
+// diffWorkflowVersions 比较工作流的两个版本
+// 查询参数 from、to 为版本号，to 默认为当前版本
+func diffWorkflowVersions(c *gin.Context) {
+	def, ok := loadWorkflow(c)
+	if !ok {
+		return
+	}
+	if c.Query("from") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "缺少参数: from",
+		})
+		return
+	}
+	from, ok := loadVersion(c, c.Query("from"))
+	if !ok {
+		return
+	}
+	to := strconv.Itoa(def.Version)
+	if c.Query("to") != "" {
+		to = c.Query("to")
+	}
+	target, ok := loadVersion(c, to)
+	if !ok {
+		return
+	}
+
+	result := diff.Workflows(from.Workflow, target.Workflow)
+	result.FromVersion = from.Version
+	result.ToVersion = target.Version
+	c.JSON(http.StatusOK, result)
+}
+
+// loadWorkflow 读取路径参数 id 对应的工作流，失败时写入错误响应
+func loadWorkflow(c *gin.Context) (types.WorkflowDefinition, bool) {
+	id := c.Param("id")
+	def, err := workflowStore.GetWorkflow(id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "工作流不存在: " + id,
+			})
+			return def, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取工作流失败: " + err.Error(),
+		})
+		return def, false
+	}
+	return def, true
+}
+
+// loadVersion 读取路径参数 id 对应工作流的指定版本，失败时写入错误响应
+func loadVersion(c *gin.Context, versionParam string) (types.WorkflowVersion, bool) {
+	id := c.Param("id")
+	versionNum, err := strconv.Atoi(versionParam)
+	if err != nil || versionNum <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "版本号无效: " + versionParam,
+		})
+		return types.WorkflowVersion{}, false
+	}
+
+	version, err := workflowStore.GetVersion(id, versionNum)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "工作流版本不存在: " + id + " v" + versionParam,
+			})
+			return version, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取工作流版本失败: " + err.Error(),
+		})
+		return version, false
+	}
+	return version, true
+}
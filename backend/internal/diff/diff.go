@@ -0,0 +1,161 @@
+package diff
+
+import (
+	"encoding/json"
+	"workflow-engine/internal/types"
+)
+
+// edgeKey 边的唯一标识
+type edgeKey struct {
+	source       string
+	sourceHandle string
+	target       string
+}
+
+// Workflows 计算两个工作流之间的结构差异
+// 节点按 ID 匹配，边按 source、sourceHandle、target 匹配
+func Workflows(from, to types.Workflow) types.WorkflowDiff {
+	result := types.WorkflowDiff{
+		AddedNodes:   []types.WorkflowNode{},
+		RemovedNodes: []types.WorkflowNode{},
+		ChangedNodes: []types.NodeChange{},
+		AddedEdges:   []types.WorkflowEdge{},
+		RemovedEdges: []types.WorkflowEdge{},
+
+		ChangedSettings: changedSettings(from, to),
+	}
+
+	// 节点差异
+	fromNodes := make(map[string]types.WorkflowNode)
+	for _, node := range from.Nodes {
+		fromNodes[node.ID] = node
+	}
+	toNodes := make(map[string]bool)
+	for _, node := range to.Nodes {
+		toNodes[node.ID] = true
+		before, ok := fromNodes[node.ID]
+		if !ok {
+			result.AddedNodes = append(result.AddedNodes, node)
+			continue
+		}
+		if fields := changedFields(before, node); len(fields) > 0 {
+			result.ChangedNodes = append(result.ChangedNodes, types.NodeChange{
+				NodeID: node.ID,
+				Fields: fields,
+				Before: before,
+				After:  node,
+			})
+		}
+	}
+	for _, node := range from.Nodes {
+		if !toNodes[node.ID] {
+			result.RemovedNodes = append(result.RemovedNodes, node)
+		}
+	}
+
+	// 边差异
+	fromEdges := make(map[edgeKey]bool)
+	for _, edge := range from.Edges {
+		fromEdges[keyOf(edge)] = true
+	}
+	toEdges := make(map[edgeKey]bool)
+	for _, edge := range to.Edges {
+		toEdges[keyOf(edge)] = true
+		if !fromEdges[keyOf(edge)] {
+			result.AddedEdges = append(result.AddedEdges, edge)
+		}
+	}
+	for _, edge := range from.Edges {
+		if !toEdges[keyOf(edge)] {
+			result.RemovedEdges = append(result.RemovedEdges, edge)
+		}
+	}
+
+	return result
+}
+
+// changedFields 比较同一节点的两个版本，返回发生变化的字段
+func changedFields(before, after types.WorkflowNode) []string {
+	var fields []string
+	if before.Type != after.Type {
+		fields = append(fields, "type")
+	}
+	if before.Label != after.Label {
+		fields = append(fields, "label")
+	}
+	if !jsonEqual(before.Config, after.Config) {
+		fields = append(fields, "config")
+	}
+	if !jsonEqual(before.Retry, after.Retry) {
+		fields = append(fields, "retry")
+	}
+	if before.Timeout != after.Timeout {
+		fields = append(fields, "timeout")
+	}
+	if !jsonEqual(before.InputMapping, after.InputMapping) {
+		fields = append(fields, "inputMapping")
+	}
+	if before.OnError != after.OnError {
+		fields = append(fields, "onError")
+	}
+	if !jsonEqual(before.Compensation, after.Compensation) {
+		fields = append(fields, "compensation")
+	}
+	if !jsonEqual(before.Body, after.Body) {
+		fields = append(fields, "body")
+	}
+	if before.Priority != after.Priority {
+		fields = append(fields, "priority")
+	}
+	if before.Position != after.Position {
+		fields = append(fields, "position")
+	}
+	return fields
+}
+
+// changedSettings 比较两个工作流的工作流级配置，返回发生变化的配置及其前后取值
+func changedSettings(from, to types.Workflow) []types.SettingChange {
+	// 未声明和空数组视为相同
+	for _, w := range []*types.Workflow{&from, &to} {
+		if len(w.Inputs) == 0 {
+			w.Inputs = nil
+		}
+		if len(w.Outputs) == 0 {
+			w.Outputs = nil
+		}
+	}
+
+	settings := []struct {
+		field         string
+		before, after interface{}
+	}{
+		{"timeout", from.Timeout, to.Timeout},
+		{"maxParallel", from.MaxParallel, to.MaxParallel},
+		{"strict", from.Strict, to.Strict},
+		{"inputs", from.Inputs, to.Inputs},
+		{"outputs", from.Outputs, to.Outputs},
+	}
+
+	changes := []types.SettingChange{}
+	for _, setting := range settings {
+		if !jsonEqual(setting.before, setting.after) {
+			changes = append(changes, types.SettingChange{
+				Field:  setting.field,
+				Before: setting.before,
+				After:  setting.after,
+			})
+		}
+	}
+	return changes
+}
+
+// jsonEqual 按 JSON 序列化结果比较两个值，避免数字类型等表示差异
+func jsonEqual(a, b interface{}) bool {
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return string(aJSON) == string(bJSON)
+}
+
+func keyOf(edge types.WorkflowEdge) edgeKey {
+	return edgeKey{source: edge.Source, sourceHandle: edge.SourceHandle, target: edge.Target}
+}
@@ -0,0 +1,952 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"workflow-engine/internal/executor"
+	"workflow-engine/internal/expr"
+	"workflow-engine/internal/params"
+	"workflow-engine/internal/types"
+	"workflow-engine/internal/validate"
+)
+
+// EventHandler 执行事件回调
+// 引擎保证同一次运行内的回调串行调用，实现方无需自行加锁
+type EventHandler func(event string, data interface{})
+
+// DefaultMaxParallel 默认的全局最大并行节点数
+const DefaultMaxParallel = 32
+
+var (
+	globalSem   = make(chan struct{}, DefaultMaxParallel)
+	globalSemMu sync.RWMutex
+)
+
+// SetMaxParallel 设置全局最大并行节点数（所有运行共享）
+func SetMaxParallel(n int) {
+	if n <= 0 {
+		n = DefaultMaxParallel
+	}
+	globalSemMu.Lock()
+	defer globalSemMu.Unlock()
+	globalSem = make(chan struct{}, n)
+}
+
+// acquireGlobal 获取全局执行槽位，返回释放函数
+// ctx 在获取到槽位前（或与槽位同时）被取消时返回 false
+func acquireGlobal(ctx context.Context) (func(), bool) {
+	globalSemMu.RLock()
+	sem := globalSem
+	globalSemMu.RUnlock()
+
+	select {
+	case sem <- struct{}{}:
+		if ctx.Err() != nil {
+			<-sem
+			return nil, false
+		}
+		return func() { <-sem }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// nodeResult 节点执行结果
+type nodeResult struct {
+	nodeID string
+	status string
+	output types.TaskOutput
+}
+
+// runner 单次工作流运行的状态
+type runner struct {
+	parent   context.Context // 调用方传入的上下文
+	ctx      context.Context // 叠加工作流超时后的运行上下文
+	workflow types.Workflow
+	inputs   types.TaskInput
+	trigger  types.TaskInput
+	env      map[string]interface{}
+	nodeMap  map[string]types.WorkflowNode
+	emit     EventHandler
+
+	mu      sync.Mutex
+	logs    []types.NodeExecutionLog
+	outputs map[string]types.TaskOutput
+	skipped map[string]bool
+
+	nodeInputs map[string]types.TaskInput // 节点实际执行时的输入，供补偿操作访问
+
+	failedNodes []string // 按 onError 策略失败后继续运行的节点，只在调度协程中访问
+	completed   []string // 按完成顺序排列的成功节点，只在调度协程中访问
+
+	arrived map[string]map[string]bool // 合并节点已到达（边生效）的前置节点，由 mu 保护
+
+	iterations map[string][]*runner // 循环节点已成功的迭代（按完成顺序），由 mu 保护，用于补偿
+
+	loop  *loopFrame             // 循环体的一次迭代，顶层运行为 nil
+	outer map[string]interface{} // 循环体可访问的外层节点输出
+}
+
+// Run 执行工作流
+// 所有前置节点都已完成的节点会被并发调度，并发数同时受 workflow.MaxParallel 和全局上限约束
+// ctx 被取消时停止调度新节点、中止执行中的节点，运行以 cancelled 状态结束
+// 超过 workflow.Timeout 时同样中止执行，运行以 timeout 状态结束
+// inputs 为调用方提供的工作流输入，按 workflow.Inputs 校验并填充默认值
+// trigger 为触发运行的外部数据，注入到没有前置节点的节点输入中，可为 nil
+func Run(ctx context.Context, workflow types.Workflow, inputs map[string]interface{}, trigger types.TaskInput, emit EventHandler) types.WorkflowExecutionResult {
+	startTime := time.Now()
+
+	// 执行前校验：任务类型、参数、边和循环依赖
+	if problems := validate.Workflow(workflow, trigger != nil); len(problems) > 0 {
+		return types.WorkflowExecutionResult{
+			Status:           "error",
+			StartTime:        startTime.Format(time.RFC3339),
+			EndTime:          time.Now().Format(time.RFC3339),
+			Logs:             []types.NodeExecutionLog{},
+			Error:            "工作流校验失败: " + validate.Summary(problems),
+			Inputs:           inputs,
+			ValidationErrors: problems,
+		}
+	}
+
+	resolvedInputs, err := params.Resolve(workflow.Inputs, inputs)
+	if err != nil {
+		return types.WorkflowExecutionResult{
+			Status:    "error",
+			StartTime: startTime.Format(time.RFC3339),
+			EndTime:   time.Now().Format(time.RFC3339),
+			Logs:      []types.NodeExecutionLog{},
+			Error:     "工作流输入错误: " + err.Error(),
+			Inputs:    inputs,
+		}
+	}
+
+	runCtx := ctx
+	if workflow.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, seconds(workflow.Timeout))
+		defer cancel()
+	}
+
+	// 拓扑排序获取执行顺序，循环依赖和无效的边已在执行前校验中排除
+	executionOrder := topologicalSort(workflow.Nodes, workflow.Edges)
+
+	r := &runner{
+		parent:   ctx,
+		ctx:      runCtx,
+		workflow: workflow,
+		inputs:   resolvedInputs,
+		trigger:  trigger,
+		env:      expr.Env(),
+		nodeMap:  make(map[string]types.WorkflowNode),
+		emit:     emit,
+		logs:     []types.NodeExecutionLog{},
+		outputs:  make(map[string]types.TaskOutput),
+		skipped:  make(map[string]bool),
+
+		nodeInputs: make(map[string]types.TaskInput),
+	}
+	for _, node := range workflow.Nodes {
+		r.nodeMap[node.ID] = node
+	}
+
+	failed := r.schedule(executionOrder)
+	endTime := time.Now()
+
+	if runCtx.Err() != nil {
+		status := r.interruptedStatus()
+		message := "工作流执行已取消"
+		if status == "timeout" {
+			// 调用方未取消时仍可执行补偿操作
+			message = fmt.Sprintf("工作流执行超时（%gs）", workflow.Timeout) + r.compensate()
+			endTime = time.Now()
+		}
+		return types.WorkflowExecutionResult{
+			Status:    status,
+			StartTime: startTime.Format(time.RFC3339),
+			EndTime:   endTime.Format(time.RFC3339),
+			Logs:      r.logs,
+			Error:     message,
+			Inputs:    r.inputs,
+		}
+	}
+
+	if failed != nil {
+		node := r.nodeMap[failed.nodeID]
+		status := "error"
+		if failed.status == "timeout" {
+			status = "timeout"
+		}
+		summary := r.compensate()
+		return types.WorkflowExecutionResult{
+			Status:      status,
+			StartTime:   startTime.Format(time.RFC3339),
+			EndTime:     time.Now().Format(time.RFC3339),
+			Logs:        r.logs,
+			FinalOutput: &failed.output,
+			Error:       "任务 \"" + node.Label + "\" 执行失败: " + failed.output.Error + summary,
+			Inputs:      r.inputs,
+		}
+	}
+
+	finalOutput, err := r.finalOutput(executionOrder)
+	if err != nil {
+		return types.WorkflowExecutionResult{
+			Status:      "error",
+			StartTime:   startTime.Format(time.RFC3339),
+			EndTime:     endTime.Format(time.RFC3339),
+			Logs:        r.logs,
+			Error:       "工作流输出错误: " + err.Error(),
+			Inputs:      r.inputs,
+			FailedNodes: r.failedNodes,
+		}
+	}
+
+	// 有节点按 onError 策略失败后继续运行时，以 partial 状态结束
+	if len(r.failedNodes) > 0 {
+		labels := make([]string, len(r.failedNodes))
+		for i, nodeID := range r.failedNodes {
+			labels[i] = "\"" + r.nodeMap[nodeID].Label + "\""
+		}
+		return types.WorkflowExecutionResult{
+			Status:      "partial",
+			StartTime:   startTime.Format(time.RFC3339),
+			EndTime:     endTime.Format(time.RFC3339),
+			Logs:        r.logs,
+			FinalOutput: finalOutput,
+			Error:       fmt.Sprintf("%d 个任务执行失败: %s", len(labels), strings.Join(labels, "、")),
+			Inputs:      r.inputs,
+			FailedNodes: r.failedNodes,
+		}
+	}
+
+	return types.WorkflowExecutionResult{
+		Status:      "success",
+		StartTime:   startTime.Format(time.RFC3339),
+		EndTime:     endTime.Format(time.RFC3339),
+		Logs:        r.logs,
+		FinalOutput: finalOutput,
+		Inputs:      r.inputs,
+	}
+}
+
+// finalOutput 计算最终输出
+// 声明了 workflow.Outputs 时按表达式从节点输出中取值，否则以拓扑序中最后一个执行成功的节点的输出作为最终输出
+func (r *runner) finalOutput(executionOrder []string) (*types.TaskOutput, error) {
+	if len(r.workflow.Outputs) > 0 {
+		scope := r.scope()
+		data := make(map[string]interface{}, len(r.workflow.Outputs))
+		for _, output := range r.workflow.Outputs {
+			value, err := expr.Evaluate(output.Value, scope)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", output.Name, err)
+			}
+			data[output.Name] = value
+		}
+		return &types.TaskOutput{Data: data}, nil
+	}
+
+	for i := len(executionOrder) - 1; i >= 0; i-- {
+		output, ok := r.outputs[executionOrder[i]]
+		if !ok || r.skipped[executionOrder[i]] || !output.IsSuccess() {
+			continue
+		}
+		return &output, nil
+	}
+	return nil, nil
+}
+
+// schedule 按依赖关系并发调度节点，返回第一个失败的节点结果
+// 出现失败后不再调度新节点，但会等待已在执行的节点结束
+func (r *runner) schedule(executionOrder []string) *nodeResult {
+	inDegree := make(map[string]int)
+	activeIn := make(map[string]int)
+	outgoing := make(map[string][]types.WorkflowEdge)
+	incoming := make(map[string][]types.WorkflowEdge)
+	for _, edge := range r.workflow.Edges {
+		inDegree[edge.Target]++
+		outgoing[edge.Source] = append(outgoing[edge.Source], edge)
+		incoming[edge.Target] = append(incoming[edge.Target], edge)
+	}
+
+	// 等待任一或 N 个分支的合并节点在到达数满足时提前执行，等待全部的合并节点与普通节点相同
+	// 等待方式无效的合并节点按等待全部调度，执行时失败
+	quorum := make(map[string]int)
+	for nodeID, edges := range incoming {
+		node := r.nodeMap[nodeID]
+		if node.Type != executor.MergeType {
+			continue
+		}
+		if n, err := executor.MergeQuorum(node.Config, len(edges)); err == nil && n < len(edges) {
+			quorum[nodeID] = n
+		}
+	}
+
+	// 就绪节点按拓扑序中的位置依次启动
+	rank := make(map[string]int, len(executionOrder))
+	var ready []string
+	for i, nodeID := range executionOrder {
+		rank[nodeID] = i
+		if inDegree[nodeID] == 0 {
+			ready = append(ready, nodeID)
+		}
+	}
+
+	finished := make(map[string]bool)              // 已结束或被跳过的节点
+	launched := make(map[string]bool)              // 已提前执行的合并节点
+	superseded := make(map[string]bool)            // 因合并节点已满足条件而取消的节点
+	cancels := make(map[string]context.CancelFunc) // 执行中节点的取消函数
+
+	// supersede 合并节点满足条件后，取消只通向该节点且尚未结束的前置节点
+	supersede := func(mergeID string) {
+		for _, edge := range incoming[mergeID] {
+			if finished[edge.Source] || superseded[edge.Source] {
+				continue
+			}
+			exclusive := true
+			for _, out := range outgoing[edge.Source] {
+				if out.Target != mergeID {
+					exclusive = false
+					break
+				}
+			}
+			if !exclusive {
+				continue
+			}
+			superseded[edge.Source] = true
+			if cancel, ok := cancels[edge.Source]; ok {
+				cancel()
+			}
+		}
+	}
+
+	// resolve 标记节点已结束，按边是否生效推进下游节点
+	// 所有入边都未生效的下游节点会被跳过，并继续向后传播
+	// blocked 记录有前置节点失败（或因此被跳过）的节点：即使其他入边生效也跳过，等待部分分支的合并节点除外
+	blocked := make(map[string]bool)
+	var resolve func(nodeID string, output *types.TaskOutput)
+	resolve = func(nodeID string, output *types.TaskOutput) {
+		finished[nodeID] = true
+		for _, edge := range outgoing[nodeID] {
+			target := edge.Target
+			inDegree[target]--
+			if launched[target] {
+				continue
+			}
+			if output != nil && r.edgeActive(edge, *output) {
+				activeIn[target]++
+				if r.nodeMap[target].Type == executor.MergeType {
+					r.arrive(target, nodeID)
+				}
+			} else if output == nil && blocked[nodeID] || output != nil && !output.IsSuccess() {
+				blocked[target] = true
+			}
+			if n, ok := quorum[target]; ok && activeIn[target] >= n {
+				launched[target] = true
+				ready = append(ready, target)
+				supersede(target)
+				continue
+			}
+			if inDegree[target] > 0 {
+				continue
+			}
+			if _, ok := quorum[target]; !ok && activeIn[target] > 0 && !blocked[target] {
+				ready = append(ready, target)
+				continue
+			}
+			reason := "分支未命中，跳过任务: "
+			if blocked[target] {
+				reason = "前置任务失败，跳过任务: "
+			}
+			r.skipNode(target, reason)
+			resolve(target, nil)
+		}
+	}
+
+	limit := r.workflow.MaxParallel
+	done := make(chan nodeResult)
+	running := 0
+	var failed *nodeResult
+
+	for {
+		sort.Slice(ready, func(i, j int) bool { return rank[ready[i]] < rank[ready[j]] })
+		for failed == nil && r.ctx.Err() == nil && len(ready) > 0 && (limit <= 0 || running < limit) {
+			nodeID := ready[0]
+			ready = ready[1:]
+			if superseded[nodeID] {
+				r.skipNode(nodeID, "合并节点已继续执行，跳过任务: ")
+				resolve(nodeID, nil)
+				continue
+			}
+			ctx, cancel := context.WithCancel(r.ctx)
+			cancels[nodeID] = cancel
+			running++
+			go func(nodeID string) {
+				done <- r.executeNode(ctx, nodeID)
+			}(nodeID)
+		}
+
+		if running == 0 {
+			return failed
+		}
+
+		result := <-done
+		running--
+		cancels[result.nodeID]()
+		delete(cancels, result.nodeID)
+
+		// 被合并节点取消的节点不视为失败
+		if superseded[result.nodeID] && !result.output.IsSuccess() {
+			resolve(result.nodeID, nil)
+			continue
+		}
+
+		if !result.output.IsSuccess() {
+			// 未被中断且节点允许失败时，跳过其下游（route 策略下激活错误分支），其他分支继续执行
+			if failed == nil && r.ctx.Err() == nil && r.tolerates(result.nodeID) {
+				r.failedNodes = append(r.failedNodes, result.nodeID)
+				resolve(result.nodeID, &result.output)
+				continue
+			}
+			if failed == nil {
+				failed = &result
+			}
+			continue
+		}
+
+		r.completed = append(r.completed, result.nodeID)
+		resolve(result.nodeID, &result.output)
+	}
+}
+
+// tolerates 判断节点失败后运行是否继续
+func (r *runner) tolerates(nodeID string) bool {
+	switch r.nodeMap[nodeID].OnError {
+	case types.OnErrorContinue, types.OnErrorRoute:
+		return true
+	}
+	return false
+}
+
+// edgeActive 判断边是否生效
+// 错误分支上的边只在源节点失败时生效；其他边要求源节点成功，且源节点未区分分支或边所在分支被选中
+func (r *runner) edgeActive(edge types.WorkflowEdge, output types.TaskOutput) bool {
+	if edge.SourceHandle == executor.ErrorHandle {
+		return !output.IsSuccess()
+	}
+	if !output.IsSuccess() {
+		return false
+	}
+	if len(output.Branches) == 0 {
+		return true
+	}
+	handle := edge.SourceHandle
+	if handle == "" {
+		handle = executor.DefaultHandle(r.nodeMap[edge.Source].Type)
+	}
+	for _, branch := range output.Branches {
+		if branch == handle {
+			return true
+		}
+	}
+	return false
+}
+
+// skipNode 记录不执行的节点，reason 为日志信息中节点名称前的跳过原因
+func (r *runner) skipNode(nodeID string, reason string) {
+	node := r.nodeMap[nodeID]
+	r.mu.Lock()
+	r.skipped[nodeID] = true
+	r.mu.Unlock()
+	r.record("node_complete", types.NodeExecutionLog{
+		NodeID:    nodeID,
+		NodeName:  node.Label,
+		Status:    "skipped",
+		Message:   reason + node.Label,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// arrive 记录合并节点的一个前置节点已到达
+func (r *runner) arrive(mergeID, nodeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.arrived == nil {
+		r.arrived = make(map[string]map[string]bool)
+	}
+	if r.arrived[mergeID] == nil {
+		r.arrived[mergeID] = make(map[string]bool)
+	}
+	r.arrived[mergeID][nodeID] = true
+}
+
+// executeNode 执行单个节点并推送开始/完成事件
+// ctx 派生自运行上下文，合并节点满足条件后可单独取消尚未结束的前置节点
+func (r *runner) executeNode(ctx context.Context, nodeID string) nodeResult {
+	node := r.nodeMap[nodeID]
+	// 循环、子工作流和合并节点本身不占用全局执行槽位，槽位留给其中的节点
+	switch node.Type {
+	case executor.ForEachType:
+		return r.executeInline(ctx, node, func(ctx context.Context, input types.TaskInput) (types.TaskOutput, []types.NodeExecutionLog) {
+			return r.runLoop(ctx, node, input), nil
+		})
+	case executor.SubWorkflowType:
+		return r.executeInline(ctx, node, func(ctx context.Context, input types.TaskInput) (types.TaskOutput, []types.NodeExecutionLog) {
+			return r.runSubWorkflow(ctx, node, input)
+		})
+	case executor.MergeType:
+		return r.executeInline(ctx, node, func(ctx context.Context, input types.TaskInput) (types.TaskOutput, []types.NodeExecutionLog) {
+			return r.runMerge(node, input), nil
+		})
+	}
+
+	// 每次尝试在执行器结束前占用一个全局执行槽位，重试等待期间不占用
+	release, ok := acquireGlobal(ctx)
+	if !ok {
+		status, message := r.abortStatus(node)
+		output := types.NewErrorOutput("任务已取消")
+		r.record("node_complete", types.NodeExecutionLog{
+			NodeID:    nodeID,
+			NodeName:  node.Label,
+			Status:    status,
+			Message:   message,
+			Output:    &output,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+		return nodeResult{nodeID: nodeID, status: status, output: output}
+	}
+
+	nodeStartTime := time.Now()
+
+	// 发送节点开始执行事件
+	r.record("node_start", types.NodeExecutionLog{
+		NodeID:    nodeID,
+		NodeName:  node.Label,
+		Status:    "running",
+		Message:   "开始执行任务: " + node.Label,
+		Timestamp: nodeStartTime.Format(time.RFC3339),
+	})
+
+	// 求值配置中的表达式和输入映射，并准备输入
+	input, err := r.resolveInput(node)
+	if err != nil {
+		release()
+		output := types.NewErrorOutput("表达式求值失败: " + err.Error())
+		endTime := time.Now()
+		r.mu.Lock()
+		r.outputs[nodeID] = output
+		r.mu.Unlock()
+		r.record("node_complete", types.NodeExecutionLog{
+			NodeID:    nodeID,
+			NodeName:  node.Label,
+			Status:    "error",
+			Message:   "任务执行失败: " + output.Error,
+			Input:     input,
+			Output:    &output,
+			Duration:  endTime.Sub(nodeStartTime).Milliseconds(),
+			Timestamp: endTime.Format(time.RFC3339),
+		})
+		return nodeResult{nodeID: nodeID, status: "error", output: output}
+	}
+
+	// 执行任务，按重试策略重试可重试的失败
+	attempts := maxAttempts(node.Retry)
+	var output types.TaskOutput
+	var timedOut bool
+	attempt := 1
+	for ; ; attempt++ {
+		attemptStartTime := time.Now()
+		output, timedOut = r.executeAttempt(ctx, node, input, release)
+		if output.IsSuccess() || ctx.Err() != nil || attempt >= attempts || !retryable(node.Retry, output) {
+			break
+		}
+
+		delay := retryDelay(node.Retry, attempt)
+		attemptEndTime := time.Now()
+		attemptOutput := output
+		r.record("node_retry", types.NodeExecutionLog{
+			NodeID:    nodeID,
+			NodeName:  node.Label,
+			Status:    "retrying",
+			Message:   fmt.Sprintf("第 %d 次执行失败，%s 后重试: %s", attempt, delay.Round(time.Millisecond), output.Error),
+			Input:     input,
+			Output:    &attemptOutput,
+			Duration:  attemptEndTime.Sub(attemptStartTime).Milliseconds(),
+			Attempt:   attempt,
+			Timestamp: attemptEndTime.Format(time.RFC3339),
+		})
+
+		if !sleepContext(ctx, delay) {
+			break
+		}
+		if release, ok = acquireGlobal(ctx); !ok {
+			break
+		}
+	}
+	endTime := time.Now()
+
+	// 未配置重试时不在日志中记录尝试次数
+	if attempts == 1 {
+		attempt = 0
+	}
+
+	// 保存输出
+	r.mu.Lock()
+	r.outputs[nodeID] = output
+	r.mu.Unlock()
+
+	// 检查结果
+	status, message := "success", "任务执行成功: "+node.Label
+	switch {
+	case output.IsSuccess():
+	case ctx.Err() != nil:
+		status, message = r.abortStatus(node)
+	case timedOut:
+		status, message = "timeout", output.Error
+	default:
+		status, message = "error", "任务执行失败: "+output.Error
+	}
+
+	r.record("node_complete", types.NodeExecutionLog{
+		NodeID:    nodeID,
+		NodeName:  node.Label,
+		Status:    status,
+		Message:   message,
+		Input:     input,
+		Output:    &output,
+		Duration:  endTime.Sub(nodeStartTime).Milliseconds(),
+		Attempts:  attempt,
+		Timestamp: endTime.Format(time.RFC3339),
+	})
+	return nodeResult{nodeID: nodeID, status: status, output: output}
+}
+
+// executeInline 执行由引擎直接运行的节点（循环、子工作流、合并）并推送开始/完成事件
+// run 返回节点输出和需要嵌套在完成日志中的子日志；这类节点不重试
+func (r *runner) executeInline(ctx context.Context, node types.WorkflowNode, run func(ctx context.Context, input types.TaskInput) (types.TaskOutput, []types.NodeExecutionLog)) nodeResult {
+	startTime := time.Now()
+	r.record("node_start", types.NodeExecutionLog{
+		NodeID:    node.ID,
+		NodeName:  node.Label,
+		Status:    "running",
+		Message:   "开始执行任务: " + node.Label,
+		Timestamp: startTime.Format(time.RFC3339),
+	})
+
+	runCtx := ctx
+	if node.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, seconds(node.Timeout))
+		defer cancel()
+	}
+
+	input, err := r.resolveInput(node)
+	var output types.TaskOutput
+	var children []types.NodeExecutionLog
+	if err != nil {
+		output = types.NewErrorOutput("表达式求值失败: " + err.Error())
+	} else {
+		output, children = run(runCtx, input)
+	}
+	endTime := time.Now()
+
+	r.mu.Lock()
+	r.outputs[node.ID] = output
+	r.mu.Unlock()
+
+	status, message := "success", "任务执行成功: "+node.Label
+	switch {
+	case output.IsSuccess():
+	case ctx.Err() != nil:
+		status, message = r.abortStatus(node)
+	case runCtx.Err() != nil:
+		output = types.NewErrorOutput(fmt.Sprintf("任务执行超时（%gs）", node.Timeout))
+		status, message = "timeout", output.Error
+	default:
+		status, message = "error", "任务执行失败: "+output.Error
+	}
+
+	r.record("node_complete", types.NodeExecutionLog{
+		NodeID:    node.ID,
+		NodeName:  node.Label,
+		Status:    status,
+		Message:   message,
+		Input:     input,
+		Output:    &output,
+		Duration:  endTime.Sub(startTime).Milliseconds(),
+		Timestamp: endTime.Format(time.RFC3339),
+		Children:  children,
+	})
+	return nodeResult{nodeID: node.ID, status: status, output: output}
+}
+
+// resolveInput 求值节点配置中的表达式和输入映射并准备输入
+// 求值失败时以原始配置准备输入，用于记录日志
+func (r *runner) resolveInput(node types.WorkflowNode) (types.TaskInput, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	scope := r.scope()
+	config, err := expr.ResolveInput(node.Config, scope)
+	if err == nil {
+		config, err = mapInputs(config, node.InputMapping, scope)
+	}
+	if err != nil {
+		config = node.Config
+	}
+	return r.prepareInput(node, config), err
+}
+
+// prepareInput 准备节点输入并记录，调用方需持有 r.mu
+// 严格模式或声明了输入映射的节点不隐式展开前置节点的输出；循环体中的节点额外接收当前项
+func (r *runner) prepareInput(node types.WorkflowNode, config types.TaskInput) types.TaskInput {
+	merge := !r.workflow.Strict && len(node.InputMapping) == 0
+	trigger := r.trigger
+	if r.loop != nil {
+		trigger = nil
+	}
+	input := prepareInput(node.ID, config, r.workflow.Edges, r.outputs, trigger, merge)
+	if r.loop != nil {
+		r.loop.inject(input, merge && len(getPredecessors(node.ID, r.workflow.Edges)) == 0)
+	}
+	if failure := r.routedError(node.ID); failure != nil {
+		input["$error"] = failure
+	}
+	r.nodeInputs[node.ID] = input
+	return input
+}
+
+// compensate 按完成顺序的逆序执行已成功节点的补偿操作
+// 单个补偿失败不影响其余补偿，返回追加到运行错误信息后的摘要，没有补偿操作时返回空字符串
+func (r *runner) compensate() string {
+	return compensationSummary(r.rollback())
+}
+
+// rollback 按完成顺序的逆序执行已成功节点的补偿操作，返回执行的补偿操作数和其中失败的数量
+// 循环节点先按完成顺序的逆序补偿各次成功迭代中的节点，再执行其自身的补偿操作
+func (r *runner) rollback() (total, failed int) {
+	for i := len(r.completed) - 1; i >= 0; i-- {
+		node := r.nodeMap[r.completed[i]]
+		r.mu.Lock()
+		iterations := r.iterations[node.ID]
+		r.mu.Unlock()
+		n, m := rollbackIterations(iterations)
+		total, failed = total+n, failed+m
+
+		if node.Compensation == nil {
+			continue
+		}
+		total++
+		if !r.executeCompensation(node) {
+			failed++
+		}
+	}
+	return total, failed
+}
+
+// rollbackIterations 按逆序补偿各次迭代中已成功的节点
+func rollbackIterations(iterations []*runner) (total, failed int) {
+	for i := len(iterations) - 1; i >= 0; i-- {
+		n, m := iterations[i].rollback()
+		total, failed = total+n, failed+m
+	}
+	return total, failed
+}
+
+// compensationSummary 补偿操作的摘要，追加到错误信息后，没有补偿操作时返回空字符串
+func compensationSummary(total, failed int) string {
+	if total == 0 {
+		return ""
+	}
+	if failed > 0 {
+		return fmt.Sprintf("；已执行 %d 个补偿操作，其中 %d 个失败", total, failed)
+	}
+	return fmt.Sprintf("；已执行 %d 个补偿操作", total)
+}
+
+// executeCompensation 执行节点的补偿操作并推送开始/完成事件，返回是否成功
+// 补偿操作使用调用方的上下文，不受工作流超时限制
+func (r *runner) executeCompensation(node types.WorkflowNode) bool {
+	compensation := node.Compensation
+	startTime := time.Now()
+	r.record("compensation_start", types.NodeExecutionLog{
+		NodeID:       node.ID,
+		NodeName:     node.Label,
+		Status:       "running",
+		Message:      "开始执行补偿操作: " + node.Label,
+		Timestamp:    startTime.Format(time.RFC3339),
+		Compensation: true,
+	})
+
+	r.mu.Lock()
+	original := map[string]interface{}{
+		"nodeId":   node.ID,
+		"nodeName": node.Label,
+		"input":    r.nodeInputs[node.ID],
+		"data":     r.outputs[node.ID].Data,
+	}
+	scope := r.scope()
+	scope["original"] = original
+	r.mu.Unlock()
+
+	input, err := expr.ResolveInput(compensation.Config, scope)
+	var output types.TaskOutput
+	if err != nil {
+		input = compensation.Config
+		output = types.NewErrorOutput("表达式求值失败: " + err.Error())
+	} else {
+		input["$original"] = original
+		ctx := r.parent
+		if compensation.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, seconds(compensation.Timeout))
+			defer cancel()
+		}
+		output = executor.Execute(ctx, compensation.Type, input)
+	}
+	endTime := time.Now()
+
+	status, message := "success", "补偿操作执行成功: "+node.Label
+	if !output.IsSuccess() {
+		status, message = "error", "补偿操作执行失败: "+output.Error
+	}
+	r.record("compensation_complete", types.NodeExecutionLog{
+		NodeID:       node.ID,
+		NodeName:     node.Label,
+		Status:       status,
+		Message:      message,
+		Input:        input,
+		Output:       &output,
+		Duration:     endTime.Sub(startTime).Milliseconds(),
+		Timestamp:    endTime.Format(time.RFC3339),
+		Compensation: true,
+	})
+	return output.IsSuccess()
+}
+
+// routedError 沿错误分支传给节点的失败信息，没有失败的来源节点时返回 nil，调用方需持有 r.mu
+// 多个来源节点失败时取边的声明顺序中的第一个
+func (r *runner) routedError(nodeID string) map[string]interface{} {
+	for _, edge := range r.workflow.Edges {
+		if edge.Target != nodeID || edge.SourceHandle != executor.ErrorHandle {
+			continue
+		}
+		output, ok := r.outputs[edge.Source]
+		if !ok || output.IsSuccess() {
+			continue
+		}
+		source := r.nodeMap[edge.Source]
+		return map[string]interface{}{
+			"nodeId":   source.ID,
+			"nodeName": source.Label,
+			"taskType": source.Type,
+			"error":    output.Error,
+			"data":     output.Data,
+		}
+	}
+	return nil
+}
+
+// executeAttempt 执行一次任务，节点超时由引擎强制执行，release 为本次尝试占用的全局执行槽位的释放函数
+// 执行器未响应取消时直接返回超时结果，不再等待其结束；槽位在执行器实际结束后才释放，全局并行上限仍然有效
+func (r *runner) executeAttempt(parent context.Context, node types.WorkflowNode, input types.TaskInput, release func()) (types.TaskOutput, bool) {
+	ctx := parent
+	if node.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, seconds(node.Timeout))
+		defer cancel()
+	}
+
+	done := make(chan types.TaskOutput, 1)
+	go func() {
+		defer release()
+		done <- executor.Execute(ctx, node.Type, input)
+	}()
+
+	var output types.TaskOutput
+	select {
+	case output = <-done:
+	case <-ctx.Done():
+		output = types.NewErrorOutput("任务已中止")
+	}
+
+	if parent.Err() == nil && ctx.Err() != nil && !output.IsSuccess() {
+		return types.NewErrorOutput(fmt.Sprintf("任务执行超时（%gs）", node.Timeout)), true
+	}
+	return output, false
+}
+
+// scope 表达式可访问的变量，调用方需持有 r.mu
+// nodes 为已完成节点的输出，inputs 为工作流输入，trigger 为触发数据，env 为环境常量
+func (r *runner) scope() expr.Scope {
+	nodes := make(map[string]interface{}, len(r.outer)+len(r.outputs))
+	for nodeID, value := range r.outer {
+		nodes[nodeID] = value
+	}
+	for nodeID, output := range r.outputs {
+		nodes[nodeID] = map[string]interface{}{
+			"error": output.Error,
+			"data":  output.Data,
+		}
+	}
+	scope := expr.Scope{
+		"nodes":   nodes,
+		"inputs":  r.inputs,
+		"trigger": r.trigger,
+		"env":     r.env,
+	}
+	if r.loop != nil {
+		scope["item"] = r.loop.item
+		scope["index"] = r.loop.index
+	}
+	return scope
+}
+
+// interruptedStatus 运行被中断时的状态：调用方取消为 cancelled，工作流超时为 timeout
+func (r *runner) interruptedStatus() string {
+	if r.parent.Err() == nil {
+		return "timeout"
+	}
+	return "cancelled"
+}
+
+// abortStatus 节点被中止时的状态和日志信息
+// 运行被中断时为 cancelled 或 timeout；运行仍在进行时节点是被合并节点单独取消的，为 cancelled
+func (r *runner) abortStatus(node types.WorkflowNode) (string, string) {
+	if r.ctx.Err() == nil {
+		return "cancelled", "合并节点已继续执行，任务已取消: " + node.Label
+	}
+	status := r.interruptedStatus()
+	if status == "timeout" {
+		return status, "工作流执行超时，任务已中止: " + node.Label
+	}
+	return status, "任务已取消: " + node.Label
+}
+
+// seconds 将秒数转换为 time.Duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// record 记录日志并推送事件
+// 循环体中的日志标记所属的循环节点和迭代序号后记录到外层运行
+func (r *runner) record(event string, log types.NodeExecutionLog) {
+	if r.loop != nil {
+		if log.LoopID == "" && log.Iteration == nil {
+			index := r.loop.index
+			log.LoopID, log.Iteration = r.loop.nodeID, &index
+		}
+		r.loop.parent.record(event, log)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, log)
+	if r.emit != nil {
+		r.emit(event, log)
+	}
+}
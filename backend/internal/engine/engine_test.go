@@ -0,0 +1,762 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"workflow-engine/internal/executor"
+	"workflow-engine/internal/types"
+)
+
+// testTaskType 测试用的任务：等待 sleep 毫秒后返回 data，设置了 fail 时以其为错误信息失败
+// ignoreCancel 为 true 时等待期间不响应取消；设置了 failTimes 时，同一 key 的前 failTimes 次执行以“暂时失败”失败
+const testTaskType = "test-task"
+
+var (
+	// tasksRunning 正在执行的测试任务数，tasksPeak 为其最大值
+	tasksRunning, tasksPeak int32
+
+	// taskCalls 各 key 的测试任务执行次数
+	taskCalls   = make(map[string]int)
+	taskCallsMu sync.Mutex
+)
+
+func init() {
+	executor.InitExecutors()
+	executor.RegisterContext(executor.TaskConfig{ID: testTaskType, Name: "测试任务"}, func(ctx context.Context, input types.TaskInput) types.TaskOutput {
+		running := atomic.AddInt32(&tasksRunning, 1)
+		defer atomic.AddInt32(&tasksRunning, -1)
+		for {
+			peak := atomic.LoadInt32(&tasksPeak)
+			if running <= peak || atomic.CompareAndSwapInt32(&tasksPeak, peak, running) {
+				break
+			}
+		}
+
+		taskCallsMu.Lock()
+		key, _ := input["key"].(string)
+		taskCalls[key]++
+		calls := taskCalls[key]
+		taskCallsMu.Unlock()
+
+		if ms, ok := input["sleep"].(float64); ok {
+			if input["ignoreCancel"] == true {
+				time.Sleep(time.Duration(ms) * time.Millisecond)
+			} else {
+				select {
+				case <-time.After(time.Duration(ms) * time.Millisecond):
+				case <-ctx.Done():
+					return types.NewErrorOutput("任务已中止")
+				}
+			}
+		}
+		if n, ok := input["failTimes"].(float64); ok && float64(calls) <= n {
+			return types.NewErrorOutput("暂时失败")
+		}
+		if message, ok := input["fail"].(string); ok {
+			return types.NewErrorOutput(message)
+		}
+		return types.NewSuccessOutput(input["data"])
+	})
+}
+
+// task 测试任务节点，label 与 ID 相同
+func task(id string, config types.TaskInput) types.WorkflowNode {
+	return types.WorkflowNode{ID: id, Type: testTaskType, Label: id, Config: config}
+}
+
+// onError 设置节点失败后的处理策略
+func onError(node types.WorkflowNode, policy string) types.WorkflowNode {
+	node.OnError = policy
+	return node
+}
+
+// failing 执行失败的测试任务节点，policy 为失败后的处理策略
+func failing(id, policy string) types.WorkflowNode {
+	return onError(task(id, types.TaskInput{"fail": id + " 失败"}), policy)
+}
+
+// timed 设置节点的超时时间和重试策略
+func timed(node types.WorkflowNode, timeout float64, retry *types.RetryPolicy) types.WorkflowNode {
+	node.Timeout = timeout
+	node.Retry = retry
+	return node
+}
+
+// at 设置节点的优先级和画布位置
+func at(node types.WorkflowNode, priority int, x, y float64) types.WorkflowNode {
+	node.Priority = priority
+	node.Position.X, node.Position.Y = x, y
+	return node
+}
+
+// edge 连接两个节点的边，handle 为源节点的输出分支
+func edge(source, target string, handle ...string) types.WorkflowEdge {
+	e := types.WorkflowEdge{Source: source, Target: target}
+	if len(handle) > 0 {
+		e.SourceHandle = handle[0]
+	}
+	return e
+}
+
+// calls 获取 key 对应的测试任务执行次数
+func calls(key string) int {
+	taskCallsMu.Lock()
+	defer taskCallsMu.Unlock()
+	return taskCalls[key]
+}
+
+// resetCalls 清零 key 对应的测试任务执行次数
+func resetCalls(key string) {
+	taskCallsMu.Lock()
+	defer taskCallsMu.Unlock()
+	delete(taskCalls, key)
+}
+
+// resetPeak 等待之前的测试中被放弃的执行器结束，并清零最大并行数
+func resetPeak() {
+	for atomic.LoadInt32(&tasksRunning) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	atomic.StoreInt32(&tasksPeak, 0)
+}
+
+// nodeStatuses 各节点最终的日志状态，不含补偿和循环迭代的日志
+func nodeStatuses(logs []types.NodeExecutionLog) map[string]string {
+	statuses := make(map[string]string)
+	for _, log := range logs {
+		if log.Compensation || log.Iteration != nil || log.Status == "running" || log.Status == "retrying" {
+			continue
+		}
+		statuses[log.NodeID] = log.Status
+	}
+	return statuses
+}
+
+// finalLog 节点最终的日志（不含补偿和循环迭代的日志），没有时返回零值
+func finalLog(logs []types.NodeExecutionLog, nodeID string) types.NodeExecutionLog {
+	var result types.NodeExecutionLog
+	for _, log := range logs {
+		if log.NodeID == nodeID && !log.Compensation && log.Iteration == nil {
+			result = log
+		}
+	}
+	return result
+}
+
+// countLogs 统计节点指定状态的日志数
+func countLogs(logs []types.NodeExecutionLog, nodeID, status string) int {
+	n := 0
+	for _, log := range logs {
+		if log.NodeID == nodeID && log.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func TestParallelScheduling(t *testing.T) {
+	sleep := types.TaskInput{"sleep": 50.0}
+	tests := []struct {
+		name     string
+		workflow types.Workflow
+		wantPeak int32
+	}{
+		{
+			name:     "独立节点并发执行",
+			workflow: types.Workflow{Nodes: []types.WorkflowNode{task("a", sleep), task("b", sleep), task("c", sleep)}},
+			wantPeak: 3,
+		},
+		{
+			name:     "maxParallel 限制单次运行的并行数",
+			workflow: types.Workflow{MaxParallel: 2, Nodes: []types.WorkflowNode{task("a", sleep), task("b", sleep), task("c", sleep)}},
+			wantPeak: 2,
+		},
+		{
+			name: "串行依赖逐个执行",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{task("a", sleep), task("b", sleep), task("c", sleep)},
+				Edges: []types.WorkflowEdge{edge("a", "b"), edge("b", "c")},
+			},
+			wantPeak: 1,
+		},
+		{
+			name: "分支并发后汇合",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{task("a", sleep), task("b", sleep), task("c", sleep), task("d", sleep)},
+				Edges: []types.WorkflowEdge{edge("a", "b"), edge("a", "c"), edge("b", "d"), edge("c", "d")},
+			},
+			wantPeak: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetPeak()
+			result := Run(context.Background(), tt.workflow, nil, nil, nil)
+			if result.Status != "success" {
+				t.Fatalf("Status = %s, want success (%s)", result.Status, result.Error)
+			}
+			for nodeID, status := range nodeStatuses(result.Logs) {
+				if status != "success" {
+					t.Errorf("node %s status = %s, want success", nodeID, status)
+				}
+			}
+			if peak := atomic.LoadInt32(&tasksPeak); peak != tt.wantPeak {
+				t.Errorf("peak running tasks = %d, want %d", peak, tt.wantPeak)
+			}
+		})
+	}
+}
+
+func TestBranchSkipping(t *testing.T) {
+	// start -> check，true 分支 t -> t2，false 分支 f -> f2，两个分支在 j 汇合；d 连在 check 的默认分支上
+	workflow := func(value string) types.Workflow {
+		return types.Workflow{
+			Nodes: []types.WorkflowNode{
+				task("start", types.TaskInput{"data": map[string]interface{}{"v": value}}),
+				{ID: "check", Type: executor.IfConditionType, Label: "check", Config: types.TaskInput{"field": "v", "operator": "equals", "value": "yes"}},
+				task("t", nil), task("t2", nil), task("f", nil), task("f2", nil), task("j", nil), task("d", nil),
+			},
+			Edges: []types.WorkflowEdge{
+				edge("start", "check"),
+				edge("check", "t", "true"), edge("t", "t2"),
+				edge("check", "f", "false"), edge("f", "f2"),
+				edge("t2", "j"), edge("f2", "j"),
+				edge("check", "d"),
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		value       string
+		want        map[string]string
+		wantSkipped string
+	}{
+		{
+			name:        "命中 true 分支",
+			value:       "yes",
+			want:        map[string]string{"start": "success", "check": "success", "t": "success", "t2": "success", "f": "skipped", "f2": "skipped", "j": "success", "d": "success"},
+			wantSkipped: "f",
+		},
+		{
+			name:        "命中 false 分支",
+			value:       "no",
+			want:        map[string]string{"start": "success", "check": "success", "t": "skipped", "t2": "skipped", "f": "success", "f2": "success", "j": "success", "d": "skipped"},
+			wantSkipped: "t",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Run(context.Background(), workflow(tt.value), nil, nil, nil)
+			if result.Status != "success" {
+				t.Fatalf("Status = %s, want success (%s)", result.Status, result.Error)
+			}
+			if got := nodeStatuses(result.Logs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("node statuses = %v, want %v", got, tt.want)
+			}
+			if got, want := finalLog(result.Logs, tt.wantSkipped).Message, "分支未命中，跳过任务: "+tt.wantSkipped; got != want {
+				t.Errorf("%s message = %q, want %q", tt.wantSkipped, got, want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		retry       *types.RetryPolicy
+		failTimes   float64
+		wantStatus  string
+		wantCalls   int
+		wantRetries int
+	}{
+		{"重试后成功", &types.RetryPolicy{MaxAttempts: 3}, 2, "success", 3, 2},
+		{"重试次数用尽", &types.RetryPolicy{MaxAttempts: 3}, 5, "error", 3, 2},
+		{"错误信息不匹配时不重试", &types.RetryPolicy{MaxAttempts: 3, RetryOn: []string{"超时"}}, 5, "error", 1, 0},
+		{"按错误信息关键字重试", &types.RetryPolicy{MaxAttempts: 3, RetryOn: []string{"暂时"}}, 1, "success", 2, 1},
+		{"状态码不匹配时不重试", &types.RetryPolicy{MaxAttempts: 3, RetryOnStatus: []int{503}}, 5, "error", 1, 0},
+		{"未配置重试", nil, 1, "error", 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCalls(t.Name())
+			node := timed(task("a", types.TaskInput{"key": t.Name(), "failTimes": tt.failTimes}), 0, tt.retry)
+			result := Run(context.Background(), types.Workflow{Nodes: []types.WorkflowNode{node}}, nil, nil, nil)
+
+			final := finalLog(result.Logs, "a")
+			if final.Status != tt.wantStatus {
+				t.Errorf("node status = %s, want %s", final.Status, tt.wantStatus)
+			}
+			// 完成日志只记录总尝试次数，未配置重试时不记录
+			wantAttempts := tt.wantCalls
+			if tt.retry == nil {
+				wantAttempts = 0
+			}
+			if final.Attempt != 0 || final.Attempts != wantAttempts {
+				t.Errorf("final log attempt = %d, attempts = %d, want 0, %d", final.Attempt, final.Attempts, wantAttempts)
+			}
+			if got := calls(t.Name()); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := countLogs(result.Logs, "a", "retrying"); got != tt.wantRetries {
+				t.Errorf("retry logs = %d, want %d", got, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		workflow    types.Workflow
+		wantStatus  string
+		wantError   string
+		wantNode    string
+		wantMessage string
+	}{
+		{
+			name:        "节点超时",
+			workflow:    types.Workflow{Nodes: []types.WorkflowNode{timed(task("a", types.TaskInput{"sleep": 1000.0}), 0.02, nil)}},
+			wantStatus:  "timeout",
+			wantError:   `任务 "a" 执行失败: 任务执行超时（0.02s）`,
+			wantNode:    "timeout",
+			wantMessage: "任务执行超时（0.02s）",
+		},
+		{
+			name:        "执行器不响应取消时按时返回",
+			workflow:    types.Workflow{Nodes: []types.WorkflowNode{timed(task("a", types.TaskInput{"sleep": 300.0, "ignoreCancel": true}), 0.02, nil)}},
+			wantStatus:  "timeout",
+			wantError:   `任务 "a" 执行失败: 任务执行超时（0.02s）`,
+			wantNode:    "timeout",
+			wantMessage: "任务执行超时（0.02s）",
+		},
+		{
+			name:        "超时后重试",
+			workflow:    types.Workflow{Nodes: []types.WorkflowNode{timed(task("a", types.TaskInput{"sleep": 1000.0}), 0.02, &types.RetryPolicy{MaxAttempts: 2})}},
+			wantStatus:  "timeout",
+			wantError:   `任务 "a" 执行失败: 任务执行超时（0.02s）`,
+			wantNode:    "timeout",
+			wantMessage: "任务执行超时（0.02s）",
+		},
+		{
+			name:        "工作流超时",
+			workflow:    types.Workflow{Timeout: 0.05, Nodes: []types.WorkflowNode{task("a", types.TaskInput{"sleep": 1000.0})}},
+			wantStatus:  "timeout",
+			wantError:   "工作流执行超时（0.05s）",
+			wantNode:    "timeout",
+			wantMessage: "工作流执行超时，任务已中止: a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			result := Run(context.Background(), tt.workflow, nil, nil, nil)
+			if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+				t.Errorf("Run took %s", elapsed)
+			}
+			if result.Status != tt.wantStatus || result.Error != tt.wantError {
+				t.Errorf("result = %s %q, want %s %q", result.Status, result.Error, tt.wantStatus, tt.wantError)
+			}
+			log := finalLog(result.Logs, "a")
+			if log.Status != tt.wantNode || log.Message != tt.wantMessage {
+				t.Errorf("node log = %s %q, want %s %q", log.Status, log.Message, tt.wantNode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestTopologicalSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []types.WorkflowNode
+		edges []types.WorkflowEdge
+		want  []string
+	}{
+		{
+			name:  "优先级大的在前",
+			nodes: []types.WorkflowNode{at(task("a", nil), 0, 0, 0), at(task("b", nil), 5, 0, 0), at(task("c", nil), 1, 0, 0)},
+			want:  []string{"b", "c", "a"},
+		},
+		{
+			name:  "先上后下、先左后右",
+			nodes: []types.WorkflowNode{at(task("a", nil), 0, 0, 100), at(task("b", nil), 0, 50, 0), at(task("c", nil), 0, 0, 0)},
+			want:  []string{"c", "b", "a"},
+		},
+		{
+			name:  "最后按节点 ID",
+			nodes: []types.WorkflowNode{task("c", nil), task("a", nil), task("b", nil)},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "依赖优先于排序",
+			nodes: []types.WorkflowNode{task("a", nil), at(task("b", nil), 9, 0, 0), task("c", nil)},
+			edges: []types.WorkflowEdge{edge("a", "b")},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "节点声明顺序不影响结果",
+			nodes: []types.WorkflowNode{task("d", nil), task("c", nil), task("b", nil), task("a", nil)},
+			edges: []types.WorkflowEdge{edge("c", "a"), edge("d", "b")},
+			want:  []string{"c", "a", "d", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topologicalSort(tt.nodes, tt.edges); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("topologicalSort = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeterministicOrder(t *testing.T) {
+	workflow := types.Workflow{
+		MaxParallel: 1,
+		Nodes: []types.WorkflowNode{
+			at(task("low", types.TaskInput{"data": "low"}), 0, 0, 200),
+			at(task("high", types.TaskInput{"data": "high"}), 3, 0, 300),
+			at(task("left", types.TaskInput{"data": "left"}), 0, 0, 100),
+			at(task("right", types.TaskInput{"data": "right"}), 0, 80, 100),
+		},
+	}
+	want := []string{"high", "left", "right", "low"}
+
+	for i := 0; i < 5; i++ {
+		var started []string
+		result := Run(context.Background(), workflow, nil, nil, func(event string, data interface{}) {
+			if event == "node_start" {
+				started = append(started, data.(types.NodeExecutionLog).NodeID)
+			}
+		})
+		if !reflect.DeepEqual(started, want) {
+			t.Fatalf("start order = %v, want %v", started, want)
+		}
+		// 未声明 outputs 时以排在最后的成功节点的输出作为最终输出
+		if result.FinalOutput == nil || result.FinalOutput.Data != "low" {
+			t.Fatalf("FinalOutput = %+v, want low", result.FinalOutput)
+		}
+	}
+}
+
+func TestOnError(t *testing.T) {
+	tests := []struct {
+		name       string
+		workflow   types.Workflow
+		wantStatus string
+		wantError  string
+		wantNodes  map[string]string
+		wantFailed []string
+	}{
+		{
+			name: "fail 策略结束运行",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{failing("a", types.OnErrorFail), task("b", nil)},
+				Edges: []types.WorkflowEdge{edge("a", "b")},
+			},
+			wantStatus: "error",
+			wantError:  `任务 "a" 执行失败: a 失败`,
+			wantNodes:  map[string]string{"a": "error"},
+		},
+		{
+			name: "continue 策略跳过下游，其他分支继续",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{failing("a", types.OnErrorContinue), task("b", nil), task("c", nil)},
+				Edges: []types.WorkflowEdge{edge("a", "b")},
+			},
+			wantStatus: "partial",
+			wantError:  `1 个任务执行失败: "a"`,
+			wantNodes:  map[string]string{"a": "error", "b": "skipped", "c": "success"},
+			wantFailed: []string{"a"},
+		},
+		{
+			name: "route 策略只执行错误分支",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{failing("a", types.OnErrorRoute), task("b", nil), task("handler", nil)},
+				Edges: []types.WorkflowEdge{edge("a", "b"), edge("a", "handler", executor.ErrorHandle)},
+			},
+			wantStatus: "partial",
+			wantError:  `1 个任务执行失败: "a"`,
+			wantNodes:  map[string]string{"a": "error", "b": "skipped", "handler": "success"},
+			wantFailed: []string{"a"},
+		},
+		{
+			name: "成功时错误分支被跳过",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{onError(task("a", nil), types.OnErrorRoute), task("b", nil), task("handler", nil)},
+				Edges: []types.WorkflowEdge{edge("a", "b"), edge("a", "handler", executor.ErrorHandle)},
+			},
+			wantStatus: "success",
+			wantNodes:  map[string]string{"a": "success", "b": "success", "handler": "skipped"},
+		},
+		{
+			name: "错误处理节点失败时结束运行",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{failing("a", types.OnErrorRoute), failing("handler", types.OnErrorFail)},
+				Edges: []types.WorkflowEdge{edge("a", "handler", executor.ErrorHandle)},
+			},
+			wantStatus: "error",
+			wantError:  `任务 "handler" 执行失败: handler 失败`,
+			wantNodes:  map[string]string{"a": "error", "handler": "error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Run(context.Background(), tt.workflow, nil, nil, nil)
+			if result.Status != tt.wantStatus || result.Error != tt.wantError {
+				t.Errorf("result = %s %q, want %s %q", result.Status, result.Error, tt.wantStatus, tt.wantError)
+			}
+			if got := nodeStatuses(result.Logs); !reflect.DeepEqual(got, tt.wantNodes) {
+				t.Errorf("node statuses = %v, want %v", got, tt.wantNodes)
+			}
+			if !reflect.DeepEqual(result.FailedNodes, tt.wantFailed) {
+				t.Errorf("FailedNodes = %v, want %v", result.FailedNodes, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestRouteErrorInput(t *testing.T) {
+	workflow := types.Workflow{
+		Nodes: []types.WorkflowNode{failing("a", types.OnErrorRoute), task("handler", types.TaskInput{"data": "{{ nodes.a.error }}"})},
+		Edges: []types.WorkflowEdge{edge("a", "handler", executor.ErrorHandle)},
+	}
+	result := Run(context.Background(), workflow, nil, nil, nil)
+
+	log := finalLog(result.Logs, "handler")
+	want := map[string]interface{}{"nodeId": "a", "nodeName": "a", "taskType": testTaskType, "error": "a 失败", "data": nil}
+	if got := log.Input["$error"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("$error = %#v, want %#v", got, want)
+	}
+	if log.Output == nil || log.Output.Data != "a 失败" {
+		t.Errorf("handler output = %+v, want a 失败", log.Output)
+	}
+}
+
+func TestOnErrorBlocksJoin(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow types.Workflow
+		want     map[string]string
+	}{
+		{
+			name: "失败节点和成功节点汇合",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{
+					failing("a", types.OnErrorContinue),
+					task("b", nil),
+					task("j", nil),
+				},
+				Edges: []types.WorkflowEdge{edge("a", "j"), edge("b", "j")},
+			},
+			want: map[string]string{"a": "error", "b": "success", "j": "skipped"},
+		},
+		{
+			name: "跳过继续向后传播",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{
+					failing("a", types.OnErrorContinue),
+					task("c", nil),
+					task("b", nil),
+					task("j", nil),
+					task("k", nil),
+				},
+				Edges: []types.WorkflowEdge{edge("a", "c"), edge("c", "j"), edge("b", "j"), edge("j", "k")},
+			},
+			want: map[string]string{"a": "error", "c": "skipped", "b": "success", "j": "skipped", "k": "skipped"},
+		},
+		{
+			name: "route 策略下错误分支执行，普通下游被跳过",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{
+					failing("a", types.OnErrorRoute),
+					task("b", nil),
+					task("handler", nil),
+					task("j", nil),
+				},
+				Edges: []types.WorkflowEdge{edge("a", "handler", executor.ErrorHandle), edge("a", "j"), edge("b", "j")},
+			},
+			want: map[string]string{"a": "error", "b": "success", "handler": "success", "j": "skipped"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Run(context.Background(), tt.workflow, nil, nil, nil)
+			if result.Status != "partial" {
+				t.Fatalf("Status = %s, want partial (%s)", result.Status, result.Error)
+			}
+			if got := nodeStatuses(result.Logs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("node statuses = %v, want %v", got, tt.want)
+			}
+			if got := finalLog(result.Logs, "j").Message; got != "前置任务失败，跳过任务: j" {
+				t.Errorf("j message = %q", got)
+			}
+		})
+	}
+}
+
+// compensated 带补偿操作的测试任务节点，compensation 为 nil 时补偿操作以原节点 ID 作为输出
+func compensated(id string, compensation types.TaskInput) types.WorkflowNode {
+	node := task(id, types.TaskInput{"data": id})
+	if compensation == nil {
+		compensation = types.TaskInput{"data": "{{ original.nodeId }}"}
+	}
+	node.Compensation = &types.Compensation{Type: testTaskType, Config: compensation}
+	return node
+}
+
+// compensations 补偿操作完成的顺序和状态，如 "a success"，循环体节点带迭代序号，如 "a[1] success"
+func compensations(t *testing.T, logs []types.NodeExecutionLog) []string {
+	var result []string
+	for _, log := range logs {
+		if !log.Compensation || log.Status == "running" {
+			continue
+		}
+		name := log.NodeID
+		if log.Iteration != nil {
+			name = fmt.Sprintf("%s[%d]", name, *log.Iteration)
+		}
+		result = append(result, name+" "+log.Status)
+		if log.Status == "success" && (log.Output == nil || log.Output.Data != log.NodeID) {
+			t.Errorf("compensation of %s output = %+v", log.NodeID, log.Output)
+		}
+	}
+	return result
+}
+
+func TestCompensation(t *testing.T) {
+	tests := []struct {
+		name       string
+		workflow   types.Workflow
+		wantStatus string
+		wantSuffix string
+		want       []string
+	}{
+		{
+			name: "按完成顺序的逆序补偿",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{compensated("a", nil), compensated("b", nil), failing("c", types.OnErrorFail)},
+				Edges: []types.WorkflowEdge{edge("a", "b"), edge("b", "c")},
+			},
+			wantStatus: "error",
+			wantSuffix: "；已执行 2 个补偿操作",
+			want:       []string{"b success", "a success"},
+		},
+		{
+			name: "补偿失败不影响其余补偿",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{compensated("a", types.TaskInput{"fail": "撤销失败"}), compensated("b", nil), failing("c", types.OnErrorFail)},
+				Edges: []types.WorkflowEdge{edge("a", "b"), edge("b", "c")},
+			},
+			wantStatus: "error",
+			wantSuffix: "；已执行 2 个补偿操作，其中 1 个失败",
+			want:       []string{"b success", "a error"},
+		},
+		{
+			name: "未执行的节点不补偿",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{compensated("a", nil), failing("c", types.OnErrorFail), compensated("b", nil)},
+				Edges: []types.WorkflowEdge{edge("a", "c"), edge("c", "b")},
+			},
+			wantStatus: "error",
+			wantSuffix: "；已执行 1 个补偿操作",
+			want:       []string{"a success"},
+		},
+		{
+			name: "continue 策略不触发补偿",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{compensated("a", nil), failing("c", types.OnErrorContinue)},
+				Edges: []types.WorkflowEdge{edge("a", "c")},
+			},
+			wantStatus: "partial",
+		},
+		{
+			name: "节点超时后补偿",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{compensated("a", nil), timed(task("c", types.TaskInput{"sleep": 1000.0}), 0.02, nil)},
+				Edges: []types.WorkflowEdge{edge("a", "c")},
+			},
+			wantStatus: "timeout",
+			wantSuffix: "；已执行 1 个补偿操作",
+			want:       []string{"a success"},
+		},
+		{
+			name: "补偿操作不能使用引擎调度的任务类型",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{func() types.WorkflowNode {
+					n := task("a", nil)
+					n.Compensation = &types.Compensation{Type: executor.ForEachType, Config: types.TaskInput{"items": []interface{}{}}}
+					return n
+				}()},
+			},
+			wantStatus: "error",
+			wantSuffix: `工作流校验失败: 节点 "a" 的补偿操作不能使用 for-each 任务类型（只能在工作流中执行）`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Run(context.Background(), tt.workflow, nil, nil, nil)
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s (%s)", result.Status, tt.wantStatus, result.Error)
+			}
+			if !strings.HasSuffix(result.Error, tt.wantSuffix) {
+				t.Errorf("Error = %q, want suffix %q", result.Error, tt.wantSuffix)
+			}
+			if got := compensations(t, result.Logs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compensations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxParallelHoldsAbandonedSlot(t *testing.T) {
+	SetMaxParallel(1)
+	defer SetMaxParallel(0)
+	resetPeak()
+
+	// a 超时后执行器仍在运行，b 需等其结束后才能获得槽位
+	a := onError(timed(task("a", types.TaskInput{"sleep": 100.0, "ignoreCancel": true}), 0.02, nil), types.OnErrorContinue)
+	workflow := types.Workflow{Nodes: []types.WorkflowNode{a, task("b", nil)}}
+
+	result := Run(context.Background(), workflow, nil, nil, nil)
+	if got := nodeStatuses(result.Logs); !reflect.DeepEqual(got, map[string]string{"a": "timeout", "b": "success"}) {
+		t.Fatalf("node statuses = %v", got)
+	}
+	if peak := atomic.LoadInt32(&tasksPeak); peak != 1 {
+		t.Errorf("peak running tasks = %d, want 1", peak)
+	}
+}
+
+func TestCancelWhileWaitingForSlot(t *testing.T) {
+	SetMaxParallel(1)
+	defer SetMaxParallel(0)
+
+	// 先获得槽位的节点执行中被取消，另一个在等待槽位时被取消
+	workflow := types.Workflow{Nodes: []types.WorkflowNode{
+		task("a", types.TaskInput{"sleep": 1000.0}),
+		task("b", types.TaskInput{"sleep": 1000.0}),
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	result := Run(ctx, workflow, nil, nil, nil)
+	if result.Status != "cancelled" {
+		t.Fatalf("Status = %s, want cancelled", result.Status)
+	}
+	if got := nodeStatuses(result.Logs); !reflect.DeepEqual(got, map[string]string{"a": "cancelled", "b": "cancelled"}) {
+		t.Errorf("node statuses = %v", got)
+	}
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"workflow-engine/internal/expr"
+	"workflow-engine/internal/types"
+)
+
+// prepareInput 准备节点输入
+// 没有前置节点的节点接收触发数据 trigger（如 webhook 请求），以 $trigger 注入并展开其 body
+// merge 为 false 时不隐式展开前置节点的 data 和触发数据的 body，只注入 $previous、$trigger
+func prepareInput(nodeID string, config types.TaskInput, edges []types.WorkflowEdge, nodeOutputs map[string]types.TaskOutput, trigger types.TaskInput, merge bool) types.TaskInput {
+	input := make(types.TaskInput)
+
+	// 复制节点配置
+	for k, v := range config {
+		input[k] = v
+	}
+
+	// 获取前置节点的输出
+	predecessors := getPredecessors(nodeID, edges)
+	if len(predecessors) > 0 {
+		previous := make(map[string]interface{})
+		for _, predID := range predecessors {
+			if output, ok := nodeOutputs[predID]; ok {
+				previous[predID] = map[string]interface{}{
+					"error": output.Error,
+					"data":  output.Data,
+				}
+			}
+		}
+		input["$previous"] = previous
+
+		// 如果只有一个前置节点，展开其 data
+		if merge && len(predecessors) == 1 {
+			if output, ok := nodeOutputs[predecessors[0]]; ok {
+				if data, ok := output.Data.(map[string]interface{}); ok {
+					for k, v := range data {
+						if _, exists := input[k]; !exists {
+							input[k] = v
+						}
+					}
+				}
+			}
+		}
+	} else if trigger != nil {
+		input["$trigger"] = trigger
+
+		if body, ok := trigger["body"].(map[string]interface{}); ok && merge {
+			for k, v := range body {
+				if _, exists := input[k]; !exists {
+					input[k] = v
+				}
+			}
+		}
+	}
+
+	return input
+}
+
+// mapInputs 按输入映射求值上游字段，覆盖配置中的同名参数
+func mapInputs(config types.TaskInput, mapping map[string]string, scope expr.Scope) (types.TaskInput, error) {
+	if len(mapping) == 0 {
+		return config, nil
+	}
+
+	params := make([]string, 0, len(mapping))
+	for param := range mapping {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	input := make(types.TaskInput, len(config)+len(mapping))
+	for k, v := range config {
+		input[k] = v
+	}
+	for _, param := range params {
+		value, err := expr.Evaluate(mapping[param], scope)
+		if err != nil {
+			return nil, fmt.Errorf("输入映射 %s: %v", param, err)
+		}
+		input[param] = value
+	}
+	return input, nil
+}
+
+// getPredecessors 获取前置节点
+func getPredecessors(nodeID string, edges []types.WorkflowEdge) []string {
+	var result []string
+	for _, edge := range edges {
+		if edge.Target == nodeID {
+			result = append(result, edge.Source)
+		}
+	}
+	return result
+}
+
+// topologicalSort 拓扑排序
+// 同时满足依赖的节点按 nodeBefore 排序，相同的工作流总是得到相同的执行顺序
+func topologicalSort(nodes []types.WorkflowNode, edges []types.WorkflowEdge) []string {
+	graph := make(map[string][]string)
+	inDegree := make(map[string]int)
+	nodeMap := make(map[string]types.WorkflowNode)
+
+	// 初始化
+	for _, node := range nodes {
+		graph[node.ID] = []string{}
+		inDegree[node.ID] = 0
+		nodeMap[node.ID] = node
+	}
+
+	// 构建图
+	for _, edge := range edges {
+		graph[edge.Source] = append(graph[edge.Source], edge.Target)
+		inDegree[edge.Target]++
+	}
+
+	// BFS，每次取出就绪节点中排在最前的一个
+	var queue []string
+	for _, node := range nodes {
+		if inDegree[node.ID] == 0 {
+			queue = append(queue, node.ID)
+		}
+	}
+
+	var result []string
+	for len(queue) > 0 {
+		sort.SliceStable(queue, func(i, j int) bool {
+			return nodeBefore(nodeMap[queue[i]], nodeMap[queue[j]])
+		})
+		current := queue[0]
+		queue = queue[1:]
+		result = append(result, current)
+
+		for _, neighbor := range graph[current] {
+			inDegree[neighbor]--
+			if inDegree[neighbor] == 0 {
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+
+	return result
+}
+
+// nodeBefore 判断同时就绪时节点 a 是否先于 b 执行
+// 依次比较优先级（大的在前）、画布位置（先上后下、先左后右）和节点 ID
+func nodeBefore(a, b types.WorkflowNode) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if a.Position.Y != b.Position.Y {
+		return a.Position.Y < b.Position.Y
+	}
+	if a.Position.X != b.Position.X {
+		return a.Position.X < b.Position.X
+	}
+	return a.ID < b.ID
+}
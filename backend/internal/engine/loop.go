@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"workflow-engine/internal/types"
+)
+
+// defaultLoopConcurrency 并行执行循环体时默认同时运行的迭代数
+const defaultLoopConcurrency = 4
+
+// loopFrame 循环体的一次迭代
+type loopFrame struct {
+	parent *runner // 循环节点所在的运行
+	nodeID string  // 循环节点 ID
+	index  int
+	item   interface{}
+}
+
+// inject 向循环体节点的输入注入当前项和序号
+// merge 为 true 时（循环体的起始节点）当前项为对象时展开其字段，不覆盖已有参数
+func (f *loopFrame) inject(input types.TaskInput, merge bool) {
+	input["$item"] = f.item
+	input["$index"] = f.index
+	if item, ok := f.item.(map[string]interface{}); ok && merge {
+		for k, v := range item {
+			if _, exists := input[k]; !exists {
+				input[k] = v
+			}
+		}
+	}
+}
+
+// runLoop 按执行方式运行各次迭代
+// 顺序执行时逐项运行；并行执行时最多同时运行 concurrency 项
+// 任一迭代失败后不再启动新的迭代，等待执行中的迭代结束后按完成顺序的逆序补偿各次迭代中已成功的节点，返回第一个失败
+// 全部成功时记录各次迭代，运行之后失败时在循环节点的补偿步骤中补偿
+func (r *runner) runLoop(ctx context.Context, node types.WorkflowNode, input types.TaskInput) types.TaskOutput {
+	items, ok := input["items"].([]interface{})
+	if !ok {
+		return types.NewErrorOutput(fmt.Sprintf("items 需为数组，实际为 %T", input["items"]))
+	}
+
+	concurrency := 1
+	if mode, _ := input["mode"].(string); mode == "parallel" {
+		concurrency = defaultLoopConcurrency
+		if n, ok := input["concurrency"].(float64); ok && n >= 1 {
+			concurrency = int(n)
+		}
+	}
+
+	r.mu.Lock()
+	outer := r.scope()["nodes"].(map[string]interface{})
+	r.mu.Unlock()
+	executionOrder := topologicalSort(node.Body.Nodes, node.Body.Edges)
+
+	results := make([]interface{}, len(items))
+	var (
+		mu       sync.Mutex
+		firstErr error
+		finished []*runner // 按完成顺序排列的迭代
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+
+launch:
+	for i, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break launch
+		}
+		mu.Lock()
+		stop := firstErr != nil
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(index int, item interface{}) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result, child, err := r.iterate(ctx, node, executionOrder, outer, index, item)
+			mu.Lock()
+			defer mu.Unlock()
+			results[index] = result
+			finished = append(finished, child)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return types.NewErrorOutput(firstErr.Error() + compensationSummary(rollbackIterations(finished)))
+	}
+	if ctx.Err() != nil {
+		return types.NewErrorOutput("任务已中止")
+	}
+
+	r.mu.Lock()
+	if r.iterations == nil {
+		r.iterations = make(map[string][]*runner)
+	}
+	r.iterations[node.ID] = finished
+	r.mu.Unlock()
+	return types.NewSuccessOutput(map[string]interface{}{
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+// iterate 运行循环体的一次迭代并推送 node_iteration 事件，返回该次迭代的最终输出和运行状态（用于补偿）
+// 循环体内的节点可通过 item、index 访问当前项，也可访问循环开始时外层已完成节点的输出
+func (r *runner) iterate(ctx context.Context, node types.WorkflowNode, executionOrder []string, outer map[string]interface{}, index int, item interface{}) (interface{}, *runner, error) {
+	body := *node.Body
+	child := &runner{
+		parent:     r.parent,
+		ctx:        ctx,
+		workflow:   body,
+		inputs:     r.inputs,
+		trigger:    r.trigger,
+		env:        r.env,
+		nodeMap:    make(map[string]types.WorkflowNode),
+		outputs:    make(map[string]types.TaskOutput),
+		skipped:    make(map[string]bool),
+		nodeInputs: make(map[string]types.TaskInput),
+		loop:       &loopFrame{parent: r, nodeID: node.ID, index: index, item: item},
+		outer:      outer,
+	}
+	for _, bodyNode := range body.Nodes {
+		child.nodeMap[bodyNode.ID] = bodyNode
+	}
+
+	startTime := time.Now()
+	failed := child.schedule(executionOrder)
+
+	var result interface{}
+	var err error
+	switch {
+	case ctx.Err() != nil:
+		err = fmt.Errorf("第 %d 项已中止", index+1)
+	case failed != nil:
+		err = fmt.Errorf("第 %d 项: 任务 %q 执行失败: %s", index+1, child.nodeMap[failed.nodeID].Label, failed.output.Error)
+	default:
+		var output *types.TaskOutput
+		output, err = child.finalOutput(executionOrder)
+		if err != nil {
+			err = fmt.Errorf("第 %d 项输出错误: %v", index+1, err)
+		} else if output != nil {
+			result = output.Data
+		}
+	}
+	endTime := time.Now()
+
+	log := types.NodeExecutionLog{
+		NodeID:    node.ID,
+		NodeName:  node.Label,
+		Status:    "success",
+		Message:   fmt.Sprintf("第 %d 项执行成功", index+1),
+		Input:     types.TaskInput{"item": item, "index": index},
+		Output:    &types.TaskOutput{Data: result},
+		Duration:  endTime.Sub(startTime).Milliseconds(),
+		Iteration: &index,
+		Timestamp: endTime.Format(time.RFC3339),
+	}
+	if err != nil {
+		log.Status, log.Message = "error", err.Error()
+		log.Output = &types.TaskOutput{Error: err.Error()}
+	}
+	r.record("node_iteration", log)
+	return result, child, err
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"workflow-engine/internal/executor"
+	"workflow-engine/internal/types"
+)
+
+// forEach 循环节点，label 与 ID 相同
+func forEach(id string, config types.TaskInput, body types.Workflow) types.WorkflowNode {
+	return types.WorkflowNode{ID: id, Type: executor.ForEachType, Label: id, Config: config, Body: &body}
+}
+
+func TestForEach(t *testing.T) {
+	items := []interface{}{1.0, 2.0, 3.0}
+	double := types.Workflow{Nodes: []types.WorkflowNode{task("double", types.TaskInput{"data": "{{ item * 2 }}"})}}
+
+	tests := []struct {
+		name       string
+		workflow   types.Workflow
+		wantStatus string
+		wantError  string
+		want       interface{} // 循环节点的 data
+		wantPeak   int32       // 大于 0 时检查循环体的最大并行数
+	}{
+		{
+			name:       "顺序执行",
+			workflow:   types.Workflow{Nodes: []types.WorkflowNode{forEach("loop", types.TaskInput{"items": items}, double)}},
+			wantStatus: "success",
+			want:       map[string]interface{}{"results": []interface{}{2.0, 4.0, 6.0}, "count": 3},
+		},
+		{
+			name: "迭代结果为循环体最后的节点输出",
+			workflow: types.Workflow{Nodes: []types.WorkflowNode{forEach("loop", types.TaskInput{"items": items}, types.Workflow{
+				Nodes: []types.WorkflowNode{task("first", types.TaskInput{"data": "{{ item }}"}), task("second", types.TaskInput{"data": "{{ nodes.first.data * 10 + index }}"})},
+				Edges: []types.WorkflowEdge{edge("first", "second")},
+			})}},
+			wantStatus: "success",
+			want:       map[string]interface{}{"results": []interface{}{10.0, 21.0, 32.0}, "count": 3},
+		},
+		{
+			name: "循环体访问外层节点输出",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{
+					task("base", types.TaskInput{"data": 100.0}),
+					forEach("loop", types.TaskInput{"items": items}, types.Workflow{Nodes: []types.WorkflowNode{task("add", types.TaskInput{"data": "{{ nodes.base.data + item }}"})}}),
+				},
+				Edges: []types.WorkflowEdge{edge("base", "loop")},
+			},
+			wantStatus: "success",
+			want:       map[string]interface{}{"results": []interface{}{101.0, 102.0, 103.0}, "count": 3},
+		},
+		{
+			name: "并行执行时结果按原顺序排列",
+			workflow: types.Workflow{Nodes: []types.WorkflowNode{forEach("loop", types.TaskInput{"items": []interface{}{1.0, 2.0, 3.0, 4.0, 5.0}, "mode": "parallel", "concurrency": 2.0}, types.Workflow{
+				Nodes: []types.WorkflowNode{task("slow", types.TaskInput{"sleep": 30.0, "data": "{{ item }}"})},
+			})}},
+			wantStatus: "success",
+			want:       map[string]interface{}{"results": []interface{}{1.0, 2.0, 3.0, 4.0, 5.0}, "count": 5},
+			wantPeak:   2,
+		},
+		{
+			name:       "空数组",
+			workflow:   types.Workflow{Nodes: []types.WorkflowNode{forEach("loop", types.TaskInput{"items": []interface{}{}}, double)}},
+			wantStatus: "success",
+			want:       map[string]interface{}{"results": []interface{}{}, "count": 0},
+		},
+		{
+			name: "迭代失败",
+			workflow: types.Workflow{Nodes: []types.WorkflowNode{forEach("loop", types.TaskInput{"items": items}, types.Workflow{
+				Nodes: []types.WorkflowNode{task("check", types.TaskInput{"fail": "{{ item == 2 ? 'bad' : null }}"})},
+			})}},
+			wantStatus: "error",
+			wantError:  `任务 "loop" 执行失败: 第 2 项: 任务 "check" 执行失败: bad`,
+		},
+		{
+			name:       "items 不是数组",
+			workflow:   types.Workflow{Nodes: []types.WorkflowNode{forEach("loop", types.TaskInput{"items": "{{ 'abc' }}"}, double)}},
+			wantStatus: "error",
+			wantError:  `任务 "loop" 执行失败: items 需为数组，实际为 string`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetPeak()
+			result := Run(context.Background(), tt.workflow, nil, nil, nil)
+			if result.Status != tt.wantStatus || result.Error != tt.wantError {
+				t.Fatalf("result = %s %q, want %s %q", result.Status, result.Error, tt.wantStatus, tt.wantError)
+			}
+			if tt.want != nil {
+				log := finalLog(result.Logs, "loop")
+				if log.Output == nil || !reflect.DeepEqual(log.Output.Data, tt.want) {
+					t.Errorf("loop output = %+v, want %v", log.Output, tt.want)
+				}
+			}
+			if peak := atomic.LoadInt32(&tasksPeak); tt.wantPeak > 0 && peak != tt.wantPeak {
+				t.Errorf("peak running tasks = %d, want %d", peak, tt.wantPeak)
+			}
+		})
+	}
+}
+
+func TestForEachIterationLogs(t *testing.T) {
+	workflow := types.Workflow{Nodes: []types.WorkflowNode{forEach("loop", types.TaskInput{"items": []interface{}{"a", "b", "c"}}, types.Workflow{
+		Nodes: []types.WorkflowNode{task("check", types.TaskInput{"fail": "{{ item == 'b' ? 'bad' : null }}"})},
+	})}}
+	result := Run(context.Background(), workflow, nil, nil, nil)
+
+	// 顺序执行时第 2 项失败后不再启动第 3 项；循环体节点的日志标记所属的循环节点和迭代序号
+	var got []string
+	for _, log := range result.Logs {
+		if log.Iteration == nil || log.Status == "running" {
+			continue
+		}
+		got = append(got, log.NodeID+" "+log.LoopID+" "+log.Status)
+	}
+	want := []string{"check loop success", "loop  success", "check loop error", "loop  error"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("iteration logs = %q, want %q", got, want)
+	}
+}
+
+func TestForEachCompensation(t *testing.T) {
+	// step 带补偿操作；check 在当前项为 2 时失败
+	body := types.Workflow{
+		Nodes: []types.WorkflowNode{compensated("step", nil), task("check", types.TaskInput{"fail": "{{ item == 2 ? 'bad' : null }}"})},
+		Edges: []types.WorkflowEdge{edge("step", "check")},
+	}
+	withCompensation := func(node types.WorkflowNode) types.WorkflowNode {
+		node.Compensation = &types.Compensation{Type: testTaskType, Config: types.TaskInput{"data": "{{ original.nodeId }}"}}
+		return node
+	}
+
+	tests := []struct {
+		name      string
+		workflow  types.Workflow
+		wantError string
+		want      []string
+	}{
+		{
+			name: "运行失败时逆序补偿各次迭代",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{compensated("before", nil), forEach("loop", types.TaskInput{"items": []interface{}{1.0, 3.0}}, body), failing("after", types.OnErrorFail)},
+				Edges: []types.WorkflowEdge{edge("before", "loop"), edge("loop", "after")},
+			},
+			wantError: `任务 "after" 执行失败: after 失败；已执行 3 个补偿操作`,
+			want:      []string{"step[1] success", "step[0] success", "before success"},
+		},
+		{
+			name: "循环节点自身的补偿在迭代之后执行",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{withCompensation(forEach("loop", types.TaskInput{"items": []interface{}{1.0, 3.0}}, body)), failing("after", types.OnErrorFail)},
+				Edges: []types.WorkflowEdge{edge("loop", "after")},
+			},
+			wantError: `任务 "after" 执行失败: after 失败；已执行 3 个补偿操作`,
+			want:      []string{"step[1] success", "step[0] success", "loop success"},
+		},
+		{
+			name:      "循环失败时补偿失败的迭代和之前成功的迭代",
+			workflow:  types.Workflow{Nodes: []types.WorkflowNode{forEach("loop", types.TaskInput{"items": []interface{}{1.0, 2.0, 3.0}}, body)}},
+			wantError: `任务 "loop" 执行失败: 第 2 项: 任务 "check" 执行失败: bad；已执行 2 个补偿操作`,
+			want:      []string{"step[1] success", "step[0] success"},
+		},
+		{
+			name: "嵌套循环",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{
+					forEach("outer", types.TaskInput{"items": []interface{}{1.0, 3.0}}, types.Workflow{Nodes: []types.WorkflowNode{
+						forEach("inner", types.TaskInput{"items": []interface{}{4.0, 5.0}}, body),
+					}}),
+					failing("after", types.OnErrorFail),
+				},
+				Edges: []types.WorkflowEdge{edge("outer", "after")},
+			},
+			wantError: `任务 "after" 执行失败: after 失败；已执行 4 个补偿操作`,
+			want:      []string{"step[1] success", "step[0] success", "step[1] success", "step[0] success"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Run(context.Background(), tt.workflow, nil, nil, nil)
+			if result.Status != "error" || result.Error != tt.wantError {
+				t.Errorf("result = %s %q, want error %q", result.Status, result.Error, tt.wantError)
+			}
+			if got := compensations(t, result.Logs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compensations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"fmt"
+	"workflow-engine/internal/executor"
+	"workflow-engine/internal/types"
+)
+
+// runMerge 按合并方式合并已到达的前置节点的 data，按入边的声明顺序处理
+// merge 将各对象的字段合并为一个对象（同名字段以后者为准），concat 将各数组拼接为一个数组（非数组的 data 作为单个元素），
+// by-label 以前置节点名称（未设置时为节点 ID）为键收集各 data
+func (r *runner) runMerge(node types.WorkflowNode, input types.TaskInput) types.TaskOutput {
+	r.mu.Lock()
+	incoming := 0
+	var sources []types.WorkflowNode
+	var outputs []types.TaskOutput
+	for _, edge := range r.workflow.Edges {
+		if edge.Target != node.ID {
+			continue
+		}
+		incoming++
+		if r.arrived[node.ID][edge.Source] {
+			sources = append(sources, r.nodeMap[edge.Source])
+			outputs = append(outputs, r.outputs[edge.Source])
+		}
+	}
+	r.mu.Unlock()
+
+	// 等待方式无效时调度已按等待全部处理，节点不能继续执行
+	if _, err := executor.MergeQuorum(node.Config, incoming); err != nil {
+		return types.NewErrorOutput(err.Error())
+	}
+
+	strategy, _ := input["strategy"].(string)
+	switch strategy {
+	case "", executor.MergeObjects:
+		merged := make(map[string]interface{})
+		for i, output := range outputs {
+			if output.Data == nil {
+				continue
+			}
+			data, ok := output.Data.(map[string]interface{})
+			if !ok {
+				return types.NewErrorOutput(fmt.Sprintf("前置任务 %q 的 data 需为对象，实际为 %T", sources[i].Label, output.Data))
+			}
+			for k, v := range data {
+				merged[k] = v
+			}
+		}
+		return types.NewSuccessOutput(merged)
+
+	case executor.MergeConcat:
+		merged := []interface{}{}
+		for _, output := range outputs {
+			switch data := output.Data.(type) {
+			case nil:
+			case []interface{}:
+				merged = append(merged, data...)
+			default:
+				merged = append(merged, data)
+			}
+		}
+		return types.NewSuccessOutput(merged)
+
+	case executor.MergeByLabel:
+		merged := make(map[string]interface{}, len(sources))
+		for i, source := range sources {
+			key := source.Label
+			if key == "" {
+				key = source.ID
+			}
+			merged[key] = outputs[i].Data
+		}
+		return types.NewSuccessOutput(merged)
+	}
+	return types.NewErrorOutput(fmt.Sprintf("合并方式无效: %s", strategy))
+}
@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+	"workflow-engine/internal/executor"
+	"workflow-engine/internal/types"
+)
+
+// merge 合并节点，label 与 ID 相同
+func merge(id string, config types.TaskInput) types.WorkflowNode {
+	return types.WorkflowNode{ID: id, Type: executor.MergeType, Label: id, Config: config}
+}
+
+func TestMerge(t *testing.T) {
+	fast := func(id string, data interface{}) types.WorkflowNode {
+		return task(id, types.TaskInput{"data": data})
+	}
+	slow := func(id string, data interface{}) types.WorkflowNode {
+		return task(id, types.TaskInput{"sleep": 1000.0, "data": data})
+	}
+	toMerge := []types.WorkflowEdge{edge("a", "m"), edge("b", "m")}
+
+	tests := []struct {
+		name       string
+		workflow   types.Workflow
+		wantStatus string
+		wantNodes  map[string]string
+		want       interface{} // 合并节点的 data
+	}{
+		{
+			name: "等待全部并合并对象",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{fast("a", map[string]interface{}{"x": 1.0, "y": 1.0}), fast("b", map[string]interface{}{"y": 2.0}), merge("m", nil)},
+				Edges: toMerge,
+			},
+			wantStatus: "success",
+			wantNodes:  map[string]string{"a": "success", "b": "success", "m": "success"},
+			want:       map[string]interface{}{"x": 1.0, "y": 2.0},
+		},
+		{
+			name: "拼接数组",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{fast("a", []interface{}{1.0, 2.0}), fast("b", 3.0), merge("m", types.TaskInput{"strategy": executor.MergeConcat})},
+				Edges: toMerge,
+			},
+			wantStatus: "success",
+			wantNodes:  map[string]string{"a": "success", "b": "success", "m": "success"},
+			want:       []interface{}{1.0, 2.0, 3.0},
+		},
+		{
+			name: "按节点名称",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{fast("a", 1.0), fast("b", "two"), merge("m", types.TaskInput{"strategy": executor.MergeByLabel})},
+				Edges: toMerge,
+			},
+			wantStatus: "success",
+			wantNodes:  map[string]string{"a": "success", "b": "success", "m": "success"},
+			want:       map[string]interface{}{"a": 1.0, "b": "two"},
+		},
+		{
+			name: "任一到达后取消执行中的分支",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{fast("a", map[string]interface{}{"x": 1.0}), slow("b", map[string]interface{}{"y": 2.0}), merge("m", types.TaskInput{"mode": executor.MergeWaitAny})},
+				Edges: toMerge,
+			},
+			wantStatus: "success",
+			wantNodes:  map[string]string{"a": "success", "b": "cancelled", "m": "success"},
+			want:       map[string]interface{}{"x": 1.0},
+		},
+		{
+			name: "N 个到达后取消其余分支",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{fast("a", []interface{}{"a"}), fast("b", []interface{}{"b"}), slow("c", []interface{}{"c"}), merge("m", types.TaskInput{"mode": executor.MergeNOfM, "count": 2.0, "strategy": executor.MergeConcat})},
+				Edges: []types.WorkflowEdge{edge("a", "m"), edge("b", "m"), edge("c", "m")},
+			},
+			wantStatus: "success",
+			wantNodes:  map[string]string{"a": "success", "b": "success", "c": "cancelled", "m": "success"},
+			want:       []interface{}{"a", "b"},
+		},
+		{
+			name: "尚未开始的分支被跳过",
+			workflow: types.Workflow{
+				MaxParallel: 1,
+				Nodes:       []types.WorkflowNode{fast("a", map[string]interface{}{"x": 1.0}), fast("b", map[string]interface{}{"y": 2.0}), merge("m", types.TaskInput{"mode": executor.MergeWaitAny})},
+				Edges:       toMerge,
+			},
+			wantStatus: "success",
+			wantNodes:  map[string]string{"a": "success", "b": "skipped", "m": "success"},
+			want:       map[string]interface{}{"x": 1.0},
+		},
+		{
+			name: "还连向其他节点的分支不取消",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{fast("a", map[string]interface{}{"x": 1.0}), task("b", types.TaskInput{"sleep": 50.0}), merge("m", types.TaskInput{"mode": executor.MergeWaitAny}), task("other", nil)},
+				Edges: []types.WorkflowEdge{edge("a", "m"), edge("b", "m"), edge("b", "other")},
+			},
+			wantStatus: "success",
+			wantNodes:  map[string]string{"a": "success", "b": "success", "m": "success", "other": "success"},
+			want:       map[string]interface{}{"x": 1.0},
+		},
+		{
+			name: "失败的分支不计入到达",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{failing("a", types.OnErrorContinue), task("b", types.TaskInput{"sleep": 20.0, "data": map[string]interface{}{"y": 2.0}}), merge("m", types.TaskInput{"mode": executor.MergeWaitAny})},
+				Edges: toMerge,
+			},
+			wantStatus: "partial",
+			wantNodes:  map[string]string{"a": "error", "b": "success", "m": "success"},
+			want:       map[string]interface{}{"y": 2.0},
+		},
+		{
+			name: "到达数不足时跳过",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{failing("a", types.OnErrorContinue), fast("b", nil), merge("m", types.TaskInput{"mode": executor.MergeNOfM, "count": 2.0})},
+				Edges: toMerge,
+			},
+			wantStatus: "partial",
+			wantNodes:  map[string]string{"a": "error", "b": "success", "m": "skipped"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			result := Run(context.Background(), tt.workflow, nil, nil, nil)
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Run took %s", elapsed)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s (%s)", result.Status, tt.wantStatus, result.Error)
+			}
+			if got := nodeStatuses(result.Logs); !reflect.DeepEqual(got, tt.wantNodes) {
+				t.Errorf("node statuses = %v, want %v", got, tt.wantNodes)
+			}
+			if tt.want != nil {
+				log := finalLog(result.Logs, "m")
+				if log.Output == nil || !reflect.DeepEqual(log.Output.Data, tt.want) {
+					t.Errorf("merge output = %+v, want %v", log.Output, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeInvalidMode(t *testing.T) {
+	workflow := types.Workflow{
+		Nodes: []types.WorkflowNode{task("a", nil), task("b", nil), merge("m", types.TaskInput{"mode": "{{ 'wait-any' }}"})},
+		Edges: []types.WorkflowEdge{edge("a", "m"), edge("b", "m")},
+	}
+
+	// 含表达式的等待方式无法通过执行前校验
+	result := Run(context.Background(), workflow, nil, nil, nil)
+	if result.Status != "error" || len(result.ValidationErrors) != 1 {
+		t.Fatalf("result = %s %q, want one validation error", result.Status, result.Error)
+	}
+	if problem := result.ValidationErrors[0]; problem.Code != "invalid_merge" || problem.NodeID != "m" || problem.Field != "mode" {
+		t.Errorf("validation error = %+v", problem)
+	}
+
+	// 绕过校验时合并节点执行失败，而不是按等待全部合并
+	tests := []struct {
+		name string
+		mode interface{}
+		want string
+	}{
+		{name: "表达式", mode: "{{ 'wait-any' }}", want: "等待方式无效: {{ 'wait-any' }}，需为 wait-all、wait-any 或 n-of-m"},
+		{name: "未知取值", mode: "first", want: "等待方式无效: first，需为 wait-all、wait-any 或 n-of-m"},
+		{name: "非字符串", mode: 1.0, want: "等待方式需为字符串，实际为 float64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := merge("m", types.TaskInput{"mode": tt.mode})
+			r := &runner{workflow: workflow, nodeMap: map[string]types.WorkflowNode{"m": node}}
+			if output := r.runMerge(node, types.TaskInput{}); output.Error != tt.want {
+				t.Errorf("output = %+v, want error %q", output, tt.want)
+			}
+		})
+	}
+}
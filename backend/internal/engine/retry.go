@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+	"workflow-engine/internal/types"
+)
+
+// maxAttempts 获取节点的最大尝试次数
+func maxAttempts(policy *types.RetryPolicy) int {
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return 1
+	}
+	return policy.MaxAttempts
+}
+
+// retryable 判断失败的输出是否可以重试
+// 未配置 retryOn 和 retryOnStatus 时所有错误都可重试
+func retryable(policy *types.RetryPolicy, output types.TaskOutput) bool {
+	if len(policy.RetryOn) == 0 && len(policy.RetryOnStatus) == 0 {
+		return true
+	}
+
+	for _, keyword := range policy.RetryOn {
+		if keyword != "" && strings.Contains(output.Error, keyword) {
+			return true
+		}
+	}
+
+	if data, ok := output.Data.(map[string]interface{}); ok {
+		var statusCode int
+		switch v := data["statusCode"].(type) {
+		case int:
+			statusCode = v
+		case float64:
+			statusCode = int(v)
+		}
+		for _, code := range policy.RetryOnStatus {
+			if code == statusCode {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// retryDelay 计算第 attempt 次尝试失败后的等待时间（指数退避 + 抖动）
+func retryDelay(policy *types.RetryPolicy, attempt int) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := policy.InitialDelay * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	if policy.Jitter > 0 {
+		jitter := math.Min(policy.Jitter, 1)
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(delay * float64(time.Second))
+}
+
+// sleepContext 等待指定时间，ctx 被取消时提前返回 false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"workflow-engine/internal/types"
+)
+
+// WorkflowLoader 加载已保存的工作流，version 为 0 时加载当前版本，同时返回实际加载的版本号
+type WorkflowLoader func(workflowID string, version int) (types.Workflow, int, error)
+
+var workflowLoader WorkflowLoader
+
+// SetWorkflowLoader 设置子工作流节点加载工作流的方式
+func SetWorkflowLoader(loader WorkflowLoader) {
+	workflowLoader = loader
+}
+
+// callStackKey 上下文中记录调用链（已保存工作流的 ID，按调用顺序）的键
+type callStackKey struct{}
+
+// WithWorkflowID 标记上下文中运行的是已保存的工作流，用于检测子工作流的递归调用
+func WithWorkflowID(ctx context.Context, workflowID string) context.Context {
+	stack := callStack(ctx)
+	next := make([]string, len(stack), len(stack)+1)
+	copy(next, stack)
+	return context.WithValue(ctx, callStackKey{}, append(next, workflowID))
+}
+
+// callStack 获取上下文中的调用链
+func callStack(ctx context.Context) []string {
+	stack, _ := ctx.Value(callStackKey{}).([]string)
+	return stack
+}
+
+// runSubWorkflow 加载并运行子工作流节点引用的工作流，返回节点输出和子工作流的节点日志
+// 子工作流以 inputs 参数作为工作流输入，声明了 outputs 时以各输出的值作为节点的 data
+// 调用链中已包含被调用的工作流时视为递归调用，不执行
+func (r *runner) runSubWorkflow(ctx context.Context, node types.WorkflowNode, input types.TaskInput) (types.TaskOutput, []types.NodeExecutionLog) {
+	workflowID, _ := input["workflowId"].(string)
+	version := 0
+	if v, ok := input["version"].(float64); ok {
+		version = int(v)
+	}
+	inputs, ok := input["inputs"].(map[string]interface{})
+	if !ok && input["inputs"] != nil {
+		return types.NewErrorOutput(fmt.Sprintf("inputs 需为对象，实际为 %T", input["inputs"])), nil
+	}
+
+	stack := callStack(ctx)
+	for _, caller := range stack {
+		if caller == workflowID {
+			chain := append(append([]string{}, stack...), workflowID)
+			return types.NewErrorOutput("子工作流递归调用: " + strings.Join(chain, " -> ")), nil
+		}
+	}
+
+	if workflowLoader == nil {
+		return types.NewErrorOutput("未配置工作流存储，无法执行子工作流"), nil
+	}
+	workflow, loadedVersion, err := workflowLoader(workflowID, version)
+	if err != nil {
+		return types.NewErrorOutput(fmt.Sprintf("加载子工作流 %s 失败: %v", workflowID, err)), nil
+	}
+
+	result := Run(WithWorkflowID(ctx, workflowID), workflow, inputs, nil, nil)
+	switch result.Status {
+	case "success", "partial":
+		var data interface{}
+		if result.FinalOutput != nil {
+			data = result.FinalOutput.Data
+		}
+		return types.NewSuccessOutput(data), result.Logs
+	}
+	return types.NewErrorOutput(fmt.Sprintf("子工作流 %s（v%d）执行失败: %s", workflowID, loadedVersion, result.Error)), result.Logs
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"workflow-engine/internal/executor"
+	"workflow-engine/internal/types"
+)
+
+// subWorkflow 子工作流节点，label 与 ID 相同
+func subWorkflow(id, workflowID string, inputs map[string]interface{}) types.WorkflowNode {
+	config := types.TaskInput{"workflowId": workflowID}
+	if inputs != nil {
+		config["inputs"] = inputs
+	}
+	return types.WorkflowNode{ID: id, Type: executor.SubWorkflowType, Label: id, Config: config}
+}
+
+// testWorkflows 子工作流测试中已保存的工作流
+var testWorkflows = map[string]types.Workflow{
+	"double": {
+		Inputs:  []types.ParamConfig{{Name: "n", Type: "number", Required: true}},
+		Nodes:   []types.WorkflowNode{task("calc", types.TaskInput{"data": "{{ inputs.n * 2 }}"})},
+		Outputs: []types.WorkflowOutput{{Name: "result", Value: "nodes.calc.data"}},
+	},
+	"broken": {Nodes: []types.WorkflowNode{failing("x", types.OnErrorFail)}},
+	"self":   {Nodes: []types.WorkflowNode{subWorkflow("call", "self", nil)}},
+	"ping":   {Nodes: []types.WorkflowNode{subWorkflow("call", "pong", nil)}},
+	"pong":   {Nodes: []types.WorkflowNode{subWorkflow("call", "ping", nil)}},
+}
+
+func TestSubWorkflow(t *testing.T) {
+	SetWorkflowLoader(func(workflowID string, version int) (types.Workflow, int, error) {
+		workflow, ok := testWorkflows[workflowID]
+		if !ok {
+			return types.Workflow{}, 0, errors.New("工作流不存在")
+		}
+		return workflow, 1, nil
+	})
+	defer SetWorkflowLoader(nil)
+
+	tests := []struct {
+		name       string
+		caller     string // 运行的已保存工作流 ID，为空时直接运行 workflow
+		workflow   types.Workflow
+		wantStatus string
+		wantError  string
+		want       interface{} // 子工作流节点的 data
+	}{
+		{
+			name:       "以声明的输出作为节点 data",
+			workflow:   types.Workflow{Nodes: []types.WorkflowNode{subWorkflow("call", "double", map[string]interface{}{"n": 21.0})}},
+			wantStatus: "success",
+			want:       map[string]interface{}{"result": 42.0},
+		},
+		{
+			name: "输入中使用表达式",
+			workflow: types.Workflow{
+				Nodes: []types.WorkflowNode{task("base", types.TaskInput{"data": 5.0}), subWorkflow("call", "double", map[string]interface{}{"n": "{{ nodes.base.data }}"})},
+				Edges: []types.WorkflowEdge{edge("base", "call")},
+			},
+			wantStatus: "success",
+			want:       map[string]interface{}{"result": 10.0},
+		},
+		{
+			name:       "子工作流输入无效",
+			workflow:   types.Workflow{Nodes: []types.WorkflowNode{subWorkflow("call", "double", nil)}},
+			wantStatus: "error",
+			wantError:  `任务 "call" 执行失败: 子工作流 double（v1）执行失败: 工作流输入错误: 缺少必填参数: n`,
+		},
+		{
+			name:       "子工作流失败",
+			workflow:   types.Workflow{Nodes: []types.WorkflowNode{subWorkflow("call", "broken", nil)}},
+			wantStatus: "error",
+			wantError:  `任务 "call" 执行失败: 子工作流 broken（v1）执行失败: 任务 "x" 执行失败: x 失败`,
+		},
+		{
+			name:       "子工作流不存在",
+			workflow:   types.Workflow{Nodes: []types.WorkflowNode{subWorkflow("call", "missing", nil)}},
+			wantStatus: "error",
+			wantError:  `任务 "call" 执行失败: 加载子工作流 missing 失败: 工作流不存在`,
+		},
+		{
+			name:       "直接调用自身",
+			caller:     "self",
+			workflow:   testWorkflows["self"],
+			wantStatus: "error",
+			wantError:  `任务 "call" 执行失败: 子工作流递归调用: self -> self`,
+		},
+		{
+			name:       "间接调用自身",
+			caller:     "ping",
+			workflow:   testWorkflows["ping"],
+			wantStatus: "error",
+			wantError:  `任务 "call" 执行失败: 子工作流 pong（v1）执行失败: 任务 "call" 执行失败: 子工作流递归调用: ping -> pong -> ping`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.caller != "" {
+				ctx = WithWorkflowID(ctx, tt.caller)
+			}
+			result := Run(ctx, tt.workflow, nil, nil, nil)
+			if result.Status != tt.wantStatus || result.Error != tt.wantError {
+				t.Fatalf("result = %s %q, want %s %q", result.Status, result.Error, tt.wantStatus, tt.wantError)
+			}
+			if tt.want == nil {
+				return
+			}
+			log := finalLog(result.Logs, "call")
+			if log.Output == nil || !reflect.DeepEqual(log.Output.Data, tt.want) {
+				t.Errorf("call output = %+v, want %v", log.Output, tt.want)
+			}
+			// 子工作流的节点日志嵌套在节点完成日志中
+			if statuses := nodeStatuses(log.Children); statuses["calc"] != "success" {
+				t.Errorf("children statuses = %v", statuses)
+			}
+		})
+	}
+}
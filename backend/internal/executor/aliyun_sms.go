@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
@@ -18,7 +19,7 @@ import (
 )
 
 func registerAliyunSMS() {
-	Register(TaskConfig{
+	RegisterContext(TaskConfig{
 		ID:          "aliyun-sms",
 		Name:        "阿里云短信",
 		Category:    "action",
@@ -95,7 +96,7 @@ type AliyunSMSResponse struct {
 	BizId     string `json:"BizId"`
 }
 
-func executeAliyunSMS(input types.TaskInput) types.TaskOutput {
+func executeAliyunSMS(ctx context.Context, input types.TaskInput) types.TaskOutput {
 	// 获取参数
 	accessKeyId, _ := input["accessKeyId"].(string)
 	accessKeySecret, _ := input["accessKeySecret"].(string)
@@ -174,8 +175,12 @@ func executeAliyunSMS(input types.TaskInput) types.TaskOutput {
 	requestURL := baseURL + "?" + queryString
 
 	// 发送请求
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return types.TaskOutput{Error: fmt.Sprintf("创建请求失败: %v", err), Data: nil}
+	}
 	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(requestURL)
+	resp, err := client.Do(req)
 	if err != nil {
 		return types.TaskOutput{Error: fmt.Sprintf("发送请求失败: %v", err), Data: nil}
 	}
@@ -7,19 +7,35 @@ import (
 	"workflow-engine/internal/types"
 )
 
+// IfConditionType 条件判断的任务类型
+const IfConditionType = "if-condition"
+
+// conditionOperators 条件判断支持的比较运算符
+var conditionOperators = []ParamOption{
+	{Label: "等于", Value: "equals"},
+	{Label: "不等于", Value: "notEquals"},
+	{Label: "大于", Value: "gt"},
+	{Label: "大于等于", Value: "gte"},
+	{Label: "小于", Value: "lt"},
+	{Label: "小于等于", Value: "lte"},
+	{Label: "包含", Value: "contains"},
+	{Label: "为空", Value: "isEmpty"},
+	{Label: "不为空", Value: "isNotEmpty"},
+}
+
 func registerIfCondition() {
 	Register(TaskConfig{
-		ID:          "if-condition",
+		ID:          IfConditionType,
 		Name:        "条件判断",
 		Category:    "condition",
-		Description: "根据条件判断是否继续执行",
+		Description: "根据条件判断走 true 或 false 分支",
 		Params: []ParamConfig{
 			{
 				Name:        "field",
 				Type:        "string",
 				Label:       "判断字段",
-				Required:    true,
-				Description: "要判断的字段路径（支持嵌套，如 data.value）",
+				Required:    false,
+				Description: "要判断的字段路径（支持嵌套，如 data.value），配置规则组时不需要",
 			},
 			{
 				Name:     "operator",
@@ -27,17 +43,7 @@ func registerIfCondition() {
 				Label:    "比较运算符",
 				Required: true,
 				Default:  "equals",
-				Options: []ParamOption{
-					{Label: "等于", Value: "equals"},
-					{Label: "不等于", Value: "notEquals"},
-					{Label: "大于", Value: "gt"},
-					{Label: "大于等于", Value: "gte"},
-					{Label: "小于", Value: "lt"},
-					{Label: "小于等于", Value: "lte"},
-					{Label: "包含", Value: "contains"},
-					{Label: "为空", Value: "isEmpty"},
-					{Label: "不为空", Value: "isNotEmpty"},
-				},
+				Options:  conditionOperators,
 			},
 			{
 				Name:        "value",
@@ -46,6 +52,13 @@ func registerIfCondition() {
 				Required:    false,
 				Description: "用于比较的值",
 			},
+			{
+				Name:        "rules",
+				Type:        "json",
+				Label:       "规则组",
+				Required:    false,
+				Description: "以 and、or、not 组合的多条规则，如 {\"and\": [{\"field\": \"status\", \"operator\": \"equals\", \"value\": 200}, {\"not\": {\"field\": \"error\", \"operator\": \"isNotEmpty\"}}]}，配置后忽略以上三项",
+			},
 			{
 				Name:        "sourceData",
 				Type:        "json",
@@ -54,10 +67,15 @@ func registerIfCondition() {
 				Description: "要判断的数据（留空则使用上一步输出）",
 			},
 		},
+		Handles: []string{"true", "false"},
 	}, executeIfCondition)
 }
 
 func executeIfCondition(input types.TaskInput) types.TaskOutput {
+	if rules := input["rules"]; rules != nil && rules != "" {
+		return executeRuleCondition(input, rules)
+	}
+
 	field, _ := input["field"].(string)
 	if field == "" {
 		return types.TaskOutput{
@@ -73,7 +91,66 @@ func executeIfCondition(input types.TaskInput) types.TaskOutput {
 
 	compareValue := input["value"]
 
-	// 获取数据源
+	// 执行条件判断，单条规则同样记录求值过程
+	var trace []interface{}
+	rule := Rule{Field: field, Operator: operator, Value: compareValue}
+	result := evaluateRule(rule, "$", conditionSource(input), &trace)
+	fieldValue := trace[0].(map[string]interface{})["fieldValue"]
+
+	branch := "false"
+	message := "条件不满足，执行 false 分支"
+	if result {
+		branch = "true"
+		message = "条件满足，执行 true 分支"
+	}
+
+	return types.TaskOutput{
+		Error: "",
+		Data: map[string]interface{}{
+			"condition":  result,
+			"field":      field,
+			"operator":   operator,
+			"value":      compareValue,
+			"fieldValue": fieldValue,
+			"trace":      trace,
+			"message":    message,
+		},
+		Branches: []string{branch},
+	}
+}
+
+// executeRuleCondition 按规则组判断，输出中的 trace 记录每条规则和规则组的求值结果
+func executeRuleCondition(input types.TaskInput, rules interface{}) types.TaskOutput {
+	rule, err := ParseRule(rules)
+	if err != nil {
+		return types.NewErrorOutput("规则组 " + err.Error())
+	}
+
+	var trace []interface{}
+	result := evaluateRule(rule, "$", conditionSource(input), &trace)
+
+	branch := "false"
+	message := "条件不满足，执行 false 分支"
+	if failed := failedRules(rule, trace); !result && len(failed) > 0 {
+		message = "条件不满足（未满足的规则: " + strings.Join(failed, "、") + "），执行 false 分支"
+	}
+	if result {
+		branch = "true"
+		message = "条件满足，执行 true 分支"
+	}
+
+	return types.TaskOutput{
+		Data: map[string]interface{}{
+			"condition": result,
+			"trace":     trace,
+			"message":   message,
+		},
+		Branches: []string{branch},
+	}
+}
+
+// conditionSource 获取条件判断的数据源：sourceData 参数，未配置时为前置节点的 data，都没有时为输入本身
+func conditionSource(input types.TaskInput) interface{} {
 	sourceData := input["sourceData"]
 	if sourceData == nil {
 		if previous, ok := input["$previous"].(map[string]interface{}); ok {
@@ -89,39 +166,9 @@ func executeIfCondition(input types.TaskInput) types.TaskOutput {
 	}
 
 	if sourceData == nil {
-		sourceData = input
-	}
-
-	// 获取字段值
-	fieldValue := getNestedValue(sourceData, field)
-
-	// 执行条件判断
-	result := evaluateCondition(fieldValue, operator, compareValue)
-
-	if !result {
-		return types.TaskOutput{
-			Error: fmt.Sprintf("条件不满足: %s %s %v", field, operator, compareValue),
-			Data: map[string]interface{}{
-				"condition":  false,
-				"field":      field,
-				"operator":   operator,
-				"value":      compareValue,
-				"fieldValue": fieldValue,
-			},
-		}
-	}
-
-	return types.TaskOutput{
-		Error: "",
-		Data: map[string]interface{}{
-			"condition":  true,
-			"field":      field,
-			"operator":   operator,
-			"value":      compareValue,
-			"fieldValue": fieldValue,
-			"message":    "条件满足，继续执行",
-		},
+		sourceData = map[string]interface{}(input)
 	}
+	return sourceData
 }
 
 func getNestedValue(data interface{}, path string) interface{} {
@@ -0,0 +1,51 @@
+package executor
+
+import (
+	"context"
+	"workflow-engine/internal/types"
+)
+
+// ForEachType 循环节点的任务类型，由引擎对数组的每一项执行节点的循环体（body）
+const ForEachType = "for-each"
+
+func registerForEach() {
+	RegisterContext(TaskConfig{
+		ID:          ForEachType,
+		Name:        "循环",
+		Category:    "control",
+		Description: "对数组中的每一项执行一次循环体，结果收集为数组",
+		Params: []ParamConfig{
+			{
+				Name:        "items",
+				Type:        "json",
+				Label:       "数组",
+				Required:    true,
+				Description: "要遍历的数组，如 {{ nodes.fetch.data.body.items }}",
+			},
+			{
+				Name:     "mode",
+				Type:     "select",
+				Label:    "执行方式",
+				Required: false,
+				Default:  "sequential",
+				Options: []ParamOption{
+					{Label: "顺序执行", Value: "sequential"},
+					{Label: "并行执行", Value: "parallel"},
+				},
+			},
+			{
+				Name:        "concurrency",
+				Type:        "number",
+				Label:       "最大并行数",
+				Required:    false,
+				Default:     4,
+				Description: "并行执行时同时运行的迭代数",
+			},
+		},
+	}, executeForEach)
+}
+
+// executeForEach 循环节点需要执行循环体，只能由引擎调度
+func executeForEach(ctx context.Context, input types.TaskInput) types.TaskOutput {
+	return types.NewErrorOutput("循环节点只能在工作流中执行")
+}
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,7 +13,7 @@ import (
 )
 
 func registerHTTPRequest() {
-	Register(TaskConfig{
+	RegisterContext(TaskConfig{
 		ID:          "http-request",
 		Name:        "HTTP 请求",
 		Category:    "action",
@@ -66,7 +67,7 @@ func registerHTTPRequest() {
 	}, executeHTTPRequest)
 }
 
-func executeHTTPRequest(input types.TaskInput) types.TaskOutput {
+func executeHTTPRequest(ctx context.Context, input types.TaskInput) types.TaskOutput {
 	url, _ := input["url"].(string)
 	if url == "" {
 		return types.TaskOutput{
@@ -105,7 +106,7 @@ func executeHTTPRequest(input types.TaskInput) types.TaskOutput {
 	}
 
 	// 创建请求
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return types.TaskOutput{
 			Error: "创建请求失败: " + err.Error(),
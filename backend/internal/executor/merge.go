@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"workflow-engine/internal/types"
+)
+
+// MergeType 合并节点的任务类型，由引擎按等待方式调度并合并前置节点的输出
+const MergeType = "merge"
+
+// 合并节点的等待方式
+const (
+	MergeWaitAll = "wait-all"
+	MergeWaitAny = "wait-any"
+	MergeNOfM    = "n-of-m"
+)
+
+// 合并节点的合并方式
+const (
+	MergeObjects = "merge"
+	MergeConcat  = "concat"
+	MergeByLabel = "by-label"
+)
+
+func registerMerge() {
+	RegisterContext(TaskConfig{
+		ID:          MergeType,
+		Name:        "合并",
+		Category:    "control",
+		Description: "等待多个分支到达后合并其输出",
+		Params: []ParamConfig{
+			{
+				Name:     "mode",
+				Type:     "select",
+				Label:    "等待方式",
+				Required: false,
+				Default:  MergeWaitAll,
+				Options: []ParamOption{
+					{Label: "等待全部", Value: MergeWaitAll},
+					{Label: "任一到达（取消其余）", Value: MergeWaitAny},
+					{Label: "N 个到达（取消其余）", Value: MergeNOfM},
+				},
+			},
+			{
+				Name:        "count",
+				Type:        "number",
+				Label:       "到达数量",
+				Required:    false,
+				Description: "等待方式为 N 个到达时需要到达的分支数",
+			},
+			{
+				Name:     "strategy",
+				Type:     "select",
+				Label:    "合并方式",
+				Required: false,
+				Default:  MergeObjects,
+				Options: []ParamOption{
+					{Label: "合并对象", Value: MergeObjects},
+					{Label: "拼接数组", Value: MergeConcat},
+					{Label: "按节点名称", Value: MergeByLabel},
+				},
+			},
+		},
+	}, executeMerge)
+}
+
+// MergeQuorum 合并节点继续执行前需要到达的分支数，incoming 为入边数
+// 等待方式和到达数量需为固定值，不支持表达式
+func MergeQuorum(config types.TaskInput, incoming int) (int, error) {
+	mode, ok := config["mode"].(string)
+	if !ok && config["mode"] != nil {
+		return 0, fmt.Errorf("等待方式需为字符串，实际为 %T", config["mode"])
+	}
+	switch mode {
+	case "", MergeWaitAll:
+		return incoming, nil
+	case MergeWaitAny:
+		return 1, nil
+	case MergeNOfM:
+		count, ok := config["count"].(float64)
+		if !ok {
+			return 0, fmt.Errorf("等待方式为 %s 时 count 需为数字", MergeNOfM)
+		}
+		if count < 1 || count > float64(incoming) || count != float64(int(count)) {
+			return 0, fmt.Errorf("count 需为 1 到 %d 之间的整数，实际为 %v", incoming, count)
+		}
+		return int(count), nil
+	}
+	return 0, fmt.Errorf("等待方式无效: %s，需为 %s、%s 或 %s", mode, MergeWaitAll, MergeWaitAny, MergeNOfM)
+}
+
+// executeMerge 合并节点需要前置节点的到达情况，只能由引擎调度
+func executeMerge(ctx context.Context, input types.TaskInput) types.TaskOutput {
+	return types.NewErrorOutput("合并节点只能在工作流中执行")
+}
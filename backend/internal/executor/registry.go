@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"sync"
 	"workflow-engine/internal/types"
 )
@@ -8,22 +9,15 @@ import (
 // TaskExecutorFunc 任务执行函数类型
 type TaskExecutorFunc func(input types.TaskInput) types.TaskOutput
 
+// ContextTaskExecutorFunc 支持取消的任务执行函数类型（v2）
+// ctx 被取消时执行器应尽快中止并返回
+type ContextTaskExecutorFunc func(ctx context.Context, input types.TaskInput) types.TaskOutput
+
 // ParamConfig 参数配置
-type ParamConfig struct {
-	Name        string        `json:"name"`
-	Type        string        `json:"type"`
-	Label       string        `json:"label"`
-	Required    bool          `json:"required"`
-	Default     interface{}   `json:"default,omitempty"`
-	Description string        `json:"description,omitempty"`
-	Options     []ParamOption `json:"options,omitempty"`
-}
+type ParamConfig = types.ParamConfig
 
 // ParamOption 参数选项
-type ParamOption struct {
-	Label string      `json:"label"`
-	Value interface{} `json:"value"`
-}
+type ParamOption = types.ParamOption
 
 // TaskConfig 任务配置
 type TaskConfig struct {
@@ -32,12 +26,13 @@ type TaskConfig struct {
 	Category    string        `json:"category"`
 	Description string        `json:"description"`
 	Params      []ParamConfig `json:"params"`
+	Handles     []string      `json:"handles,omitempty"` // 输出分支，第一个为未指定分支的边所使用的默认分支
 }
 
 // registeredExecutor 注册的执行器
 type registeredExecutor struct {
 	config   TaskConfig
-	executor TaskExecutorFunc
+	executor ContextTaskExecutorFunc
 }
 
 var (
@@ -47,6 +42,13 @@ var (
 
 // Register 注册执行器
 func Register(config TaskConfig, executor TaskExecutorFunc) {
+	RegisterContext(config, func(ctx context.Context, input types.TaskInput) types.TaskOutput {
+		return executor(input)
+	})
+}
+
+// RegisterContext 注册支持取消的执行器
+func RegisterContext(config TaskConfig, executor ContextTaskExecutorFunc) {
 	mu.Lock()
 	defer mu.Unlock()
 	registry[config.ID] = registeredExecutor{
@@ -56,7 +58,7 @@ func Register(config TaskConfig, executor TaskExecutorFunc) {
 }
 
 // Get 获取执行器
-func Get(taskType string) (TaskExecutorFunc, bool) {
+func Get(taskType string) (ContextTaskExecutorFunc, bool) {
 	mu.RLock()
 	defer mu.RUnlock()
 	if reg, ok := registry[taskType]; ok {
@@ -86,8 +88,38 @@ func GetAllConfigs() []TaskConfig {
 	return configs
 }
 
+// ErrorHandle 错误分支，任何节点都可使用；节点的 onError 为 route 时，失败后只有该分支上的边生效
+const ErrorHandle = "error"
+
+// DefaultHandle 获取任务类型的默认输出分支
+func DefaultHandle(taskType string) string {
+	config, ok := GetConfig(taskType)
+	if !ok || len(config.Handles) == 0 {
+		return ""
+	}
+	return config.Handles[0]
+}
+
+// NodeHandles 获取节点的输出分支：switch 节点为各分支的名称加默认分支，其他任务类型为其声明的分支
+// switch 节点的 cases 无效（或为表达式）时只有默认分支
+func NodeHandles(taskType string, config types.TaskInput) []string {
+	taskConfig, ok := GetConfig(taskType)
+	if !ok || taskType != SwitchType {
+		return taskConfig.Handles
+	}
+	cases, err := SwitchCases(config["cases"])
+	if err != nil {
+		return taskConfig.Handles
+	}
+	handles := make([]string, 0, len(cases)+len(taskConfig.Handles))
+	for _, c := range cases {
+		handles = append(handles, c.Name)
+	}
+	return append(handles, taskConfig.Handles...)
+}
+
 // Execute 执行任务
-func Execute(taskType string, input types.TaskInput) types.TaskOutput {
+func Execute(ctx context.Context, taskType string, input types.TaskInput) types.TaskOutput {
 	executor, ok := Get(taskType)
 	if !ok {
 		return types.TaskOutput{
@@ -95,13 +127,17 @@ func Execute(taskType string, input types.TaskInput) types.TaskOutput {
 			Data:  nil,
 		}
 	}
-	return executor(input)
+	return executor(ctx, input)
 }
 
 // InitExecutors 初始化所有执行器
 func InitExecutors() {
 	registerHTTPRequest()
 	registerIfCondition()
+	registerSwitch()
 	registerSendEmail()
 	registerAliyunSMS()
+	registerForEach()
+	registerSubWorkflow()
+	registerMerge()
 }
@@ -0,0 +1,152 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule 条件规则：单条比较规则，或以 and、or、not 组合的规则组
+type Rule struct {
+	Combinator string // and、or、not，为空时为单条比较规则
+	Rules      []Rule // 规则组的子规则，not 只有一条
+	Field      string
+	Operator   string
+	Value      interface{}
+}
+
+// ParseRule 解析条件规则
+// 单条规则为 {"field", "operator", "value"}（operator 默认为 equals），规则组为 {"and": [...]}、{"or": [...]} 或 {"not": {...}}，可任意嵌套
+func ParseRule(value interface{}) (Rule, error) {
+	return parseRule(value, "$")
+}
+
+// parseRule 解析 path 处的规则，错误信息以 path 指出问题所在
+func parseRule(value interface{}, path string) (Rule, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return Rule{}, fmt.Errorf("%s 需为对象，实际为 %T", path, value)
+	}
+
+	var combinators []string
+	for _, key := range []string{"and", "or", "not"} {
+		if _, ok := m[key]; ok {
+			combinators = append(combinators, key)
+		}
+	}
+	if len(combinators) > 1 {
+		return Rule{}, fmt.Errorf("%s 只能包含 and、or、not 中的一个，实际包含 %s", path, strings.Join(combinators, "、"))
+	}
+
+	if len(combinators) == 1 {
+		combinator := combinators[0]
+		rule := Rule{Combinator: combinator}
+		if combinator == "not" {
+			child, err := parseRule(m["not"], path+".not")
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.Rules = []Rule{child}
+			return rule, nil
+		}
+
+		items, ok := m[combinator].([]interface{})
+		if !ok || len(items) == 0 {
+			return Rule{}, fmt.Errorf("%s.%s 需为非空数组", path, combinator)
+		}
+		for i, item := range items {
+			child, err := parseRule(item, fmt.Sprintf("%s.%s[%d]", path, combinator, i))
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.Rules = append(rule.Rules, child)
+		}
+		return rule, nil
+	}
+
+	rule := Rule{Operator: "equals", Value: m["value"]}
+	rule.Field, _ = m["field"].(string)
+	if operator, ok := m["operator"].(string); ok && operator != "" {
+		rule.Operator = operator
+	}
+	if rule.Field == "" {
+		return Rule{}, fmt.Errorf("%s 缺少 field", path)
+	}
+	if !validOperator(rule.Operator) {
+		return Rule{}, fmt.Errorf("%s 的 operator 无效: %s", path, rule.Operator)
+	}
+	return rule, nil
+}
+
+// evaluateRule 对数据源求值规则，并按先序把每条规则和规则组的结果追加到 trace
+// 为了完整展示各条规则的结果，规则组不短路求值
+func evaluateRule(rule Rule, path string, sourceData interface{}, trace *[]interface{}) bool {
+	if rule.Combinator == "" {
+		fieldValue := getNestedValue(sourceData, rule.Field)
+		result := evaluateCondition(fieldValue, rule.Operator, rule.Value)
+		*trace = append(*trace, map[string]interface{}{
+			"path":       path,
+			"field":      rule.Field,
+			"operator":   rule.Operator,
+			"value":      rule.Value,
+			"fieldValue": fieldValue,
+			"result":     result,
+		})
+		return result
+	}
+
+	entry := map[string]interface{}{"path": path, "combinator": rule.Combinator}
+	*trace = append(*trace, entry)
+
+	var result bool
+	switch rule.Combinator {
+	case "not":
+		result = !evaluateRule(rule.Rules[0], path+".not", sourceData, trace)
+	case "and":
+		result = true
+		for i, child := range rule.Rules {
+			if !evaluateRule(child, fmt.Sprintf("%s.and[%d]", path, i), sourceData, trace) {
+				result = false
+			}
+		}
+	case "or":
+		for i, child := range rule.Rules {
+			if evaluateRule(child, fmt.Sprintf("%s.or[%d]", path, i), sourceData, trace) {
+				result = true
+			}
+		}
+	}
+	entry["result"] = result
+	return result
+}
+
+// failedRules 列出决定规则结果为 false 的规则路径，trace 为 evaluateRule 对同一规则记录的求值结果
+// and 组深入结果为 false 的子规则；or 组结果为 false 时深入所有子规则；not 组的子规则为 true 时报告 not 组本身
+func failedRules(rule Rule, trace []interface{}) []string {
+	var paths []string
+	pos := 0
+	// walk 按先序消费 rule 对应的 trace 条目，explain 表示需要解释该规则为何为 false
+	var walk func(rule Rule, explain bool)
+	walk = func(rule Rule, explain bool) {
+		entry := trace[pos].(map[string]interface{})
+		pos++
+		explain = explain && entry["result"] == false
+
+		switch rule.Combinator {
+		case "":
+			if explain {
+				paths = append(paths, entry["path"].(string))
+			}
+		case "not":
+			walk(rule.Rules[0], false)
+			if explain {
+				paths = append(paths, entry["path"].(string))
+			}
+		default:
+			for _, child := range rule.Rules {
+				walk(child, explain)
+			}
+		}
+	}
+	walk(rule, true)
+	return paths
+}
@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFailedRules(t *testing.T) {
+	pass := map[string]interface{}{"field": "a", "operator": "equals", "value": 1}
+	fail := map[string]interface{}{"field": "a", "operator": "equals", "value": 2}
+	and := func(rules ...interface{}) map[string]interface{} { return map[string]interface{}{"and": rules} }
+	or := func(rules ...interface{}) map[string]interface{} { return map[string]interface{}{"or": rules} }
+	not := func(rule interface{}) map[string]interface{} { return map[string]interface{}{"not": rule} }
+
+	tests := []struct {
+		name   string
+		rules  map[string]interface{}
+		result bool
+		failed []string
+	}{
+		{"单条规则满足", pass, true, nil},
+		{"单条规则不满足", fail, false, []string{"$"}},
+		{"and 只报告不满足的子规则", and(pass, fail, pass), false, []string{"$.and[1]"}},
+		{"and 中满足的 or 不报告", and(pass, or(fail, pass), not(pass)), false, []string{"$.and[2]"}},
+		{"and 中不满足的 or 报告其所有子规则", and(fail, or(fail, fail)), false, []string{"$.and[0]", "$.and[1].or[0]", "$.and[1].or[1]"}},
+		{"or 深入不满足的 and", or(fail, and(pass, fail)), false, []string{"$.or[0]", "$.or[1].and[1]"}},
+		{"or 满足时不报告", or(fail, pass), true, nil},
+		{"not 的子规则满足时报告 not 本身", not(and(pass, pass)), false, []string{"$"}},
+		{"not 的子规则不满足时结果为 true", not(fail), true, nil},
+		{"双重 not", not(not(fail)), false, []string{"$"}},
+		{"嵌套的 not", and(or(not(pass), fail), not(fail)), false, []string{"$.and[0].or[0]", "$.and[0].or[1]"}},
+	}
+
+	source := map[string]interface{}{"a": 1}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rules)
+			if err != nil {
+				t.Fatalf("ParseRule: %v", err)
+			}
+			var trace []interface{}
+			if result := evaluateRule(rule, "$", source, &trace); result != tt.result {
+				t.Fatalf("result = %v, want %v", result, tt.result)
+			}
+			if failed := failedRules(rule, trace); !reflect.DeepEqual(failed, tt.failed) {
+				t.Errorf("failedRules = %v, want %v", failed, tt.failed)
+			}
+		})
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules interface{}
+		want  string
+	}{
+		{"非对象", "x", "$ 需为对象，实际为 string"},
+		{"缺少 field", map[string]interface{}{"operator": "gt"}, "$ 缺少 field"},
+		{"无效的 operator", map[string]interface{}{"field": "a", "operator": "zz"}, "$ 的 operator 无效: zz"},
+		{"多个组合方式", map[string]interface{}{"and": []interface{}{}, "not": 1}, "$ 只能包含 and、or、not 中的一个，实际包含 and、not"},
+		{"空的 and", map[string]interface{}{"and": []interface{}{}}, "$.and 需为非空数组"},
+		{"嵌套规则的路径", map[string]interface{}{"or": []interface{}{map[string]interface{}{"not": map[string]interface{}{}}}}, "$.or[0].not 缺少 field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRule(tt.rules)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ParseRule error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/smtp"
@@ -9,7 +10,7 @@ import (
 )
 
 func registerSendEmail() {
-	Register(TaskConfig{
+	RegisterContext(TaskConfig{
 		ID:          "send-email",
 		Name:        "发送邮件",
 		Category:    "action",
@@ -70,7 +71,7 @@ func registerSendEmail() {
 	}, executeSendEmail)
 }
 
-func executeSendEmail(input types.TaskInput) types.TaskOutput {
+func executeSendEmail(ctx context.Context, input types.TaskInput) types.TaskOutput {
 	// 获取参数
 	to, _ := input["to"].(string)
 	subject, _ := input["subject"].(string)
@@ -133,7 +134,7 @@ func executeSendEmail(input types.TaskInput) types.TaskOutput {
 	allRecipients := append(toAddrs, ccAddrs...)
 
 	// 使用 Gmail SMTP (SSL/TLS 端口 465)
-	err := sendMailGmail(from, password, allRecipients, msg)
+	err := sendMailGmail(ctx, from, password, allRecipients, msg)
 
 	if err != nil {
 		return types.TaskOutput{
@@ -168,7 +169,7 @@ func parseEmailAddresses(addresses string) []string {
 }
 
 // sendMailGmail 通过 Gmail SMTP 发送邮件
-func sendMailGmail(from, password string, to []string, msg []byte) error {
+func sendMailGmail(ctx context.Context, from, password string, to []string, msg []byte) error {
 	// Gmail SMTP 配置
 	smtpHost := "smtp.gmail.com"
 	smtpPort := 465
@@ -182,11 +183,18 @@ func sendMailGmail(from, password string, to []string, msg []byte) error {
 	}
 
 	// 建立 TLS 连接
-	conn, err := tls.Dial("tcp", addr, tlsConfig)
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
 		return fmt.Errorf("连接失败: %v", err)
 	}
 
+	// 取消时关闭连接，中断阻塞中的 SMTP 交互
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
 	// 创建 SMTP 客户端
 	client, err := smtp.NewClient(conn, smtpHost)
 	if err != nil {
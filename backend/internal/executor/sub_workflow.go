@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"context"
+	"workflow-engine/internal/types"
+)
+
+// SubWorkflowType 子工作流节点的任务类型，由引擎加载并运行已保存的工作流
+const SubWorkflowType = "sub-workflow"
+
+func registerSubWorkflow() {
+	RegisterContext(TaskConfig{
+		ID:          SubWorkflowType,
+		Name:        "子工作流",
+		Category:    "control",
+		Description: "调用已保存的工作流，以其输出作为任务结果",
+		Params: []ParamConfig{
+			{
+				Name:        "workflowId",
+				Type:        "string",
+				Label:       "工作流 ID",
+				Required:    true,
+				Description: "要调用的已保存工作流的 ID",
+			},
+			{
+				Name:        "version",
+				Type:        "number",
+				Label:       "版本",
+				Required:    false,
+				Description: "要调用的版本号，不填时使用当前版本",
+			},
+			{
+				Name:        "inputs",
+				Type:        "json",
+				Label:       "工作流输入",
+				Required:    false,
+				Description: "传给子工作流的输入，如 {\"orderId\": \"{{ nodes.fetch.data.body.id }}\"}",
+			},
+		},
+	}, executeSubWorkflow)
+}
+
+// executeSubWorkflow 子工作流节点需要加载并运行其他工作流，只能由引擎调度
+func executeSubWorkflow(ctx context.Context, input types.TaskInput) types.TaskOutput {
+	return types.NewErrorOutput("子工作流节点只能在工作流中执行")
+}
@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"workflow-engine/internal/types"
+)
+
+// SwitchType 多分支判断的任务类型，各分支的名称即节点的输出分支
+const SwitchType = "switch"
+
+// SwitchDefaultHandle 没有分支命中时使用的默认分支，也是未指定分支的边所使用的分支
+const SwitchDefaultHandle = "default"
+
+// SwitchCase 多分支判断中的一个分支
+type SwitchCase struct {
+	Name string
+	Rule Rule
+}
+
+func registerSwitch() {
+	Register(TaskConfig{
+		ID:          SwitchType,
+		Name:        "多分支判断",
+		Category:    "condition",
+		Description: "按顺序判断多个分支条件，走命中的分支或 default 分支",
+		Params: []ParamConfig{
+			{
+				Name:        "cases",
+				Type:        "json",
+				Label:       "分支",
+				Required:    true,
+				Description: "分支列表，如 [{\"name\": \"high\", \"field\": \"amount\", \"operator\": \"gt\", \"value\": 1000}]，分支也可用 rules 配置规则组",
+			},
+			{
+				Name:     "mode",
+				Type:     "select",
+				Label:    "命中方式",
+				Required: false,
+				Default:  "first",
+				Options: []ParamOption{
+					{Label: "第一个命中的分支", Value: "first"},
+					{Label: "所有命中的分支", Value: "all"},
+				},
+			},
+			{
+				Name:        "sourceData",
+				Type:        "json",
+				Label:       "数据源",
+				Required:    false,
+				Description: "要判断的数据（留空则使用上一步输出）",
+			},
+		},
+		Handles: []string{SwitchDefaultHandle},
+	}, executeSwitch)
+}
+
+// SwitchCases 解析 cases 参数
+// 每个分支需为包含 name 和 field（或规则组 rules）的对象，operator 默认为 equals；分支名称不能重复，也不能与 default、error 分支同名
+func SwitchCases(value interface{}) ([]SwitchCase, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("需为数组，实际为 %T", value)
+	}
+
+	cases := make([]SwitchCase, 0, len(items))
+	names := make(map[string]bool, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("第 %d 项需为对象", i+1)
+		}
+		c := SwitchCase{Rule: Rule{Operator: "equals", Value: m["value"]}}
+		c.Name, _ = m["name"].(string)
+		c.Rule.Field, _ = m["field"].(string)
+		if operator, ok := m["operator"].(string); ok && operator != "" {
+			c.Rule.Operator = operator
+		}
+
+		switch {
+		case c.Name == "":
+			return nil, fmt.Errorf("第 %d 项缺少 name", i+1)
+		case c.Name == SwitchDefaultHandle || c.Name == ErrorHandle:
+			return nil, fmt.Errorf("第 %d 项的 name 不能为 %s", i+1, c.Name)
+		case names[c.Name]:
+			return nil, fmt.Errorf("第 %d 项的 name 重复: %s", i+1, c.Name)
+		}
+
+		if rules, ok := m["rules"]; ok {
+			rule, err := ParseRule(rules)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 项（%s）的 rules %v", i+1, c.Name, err)
+			}
+			c.Rule = rule
+		} else if c.Rule.Field == "" {
+			return nil, fmt.Errorf("第 %d 项（%s）缺少 field", i+1, c.Name)
+		} else if !validOperator(c.Rule.Operator) {
+			return nil, fmt.Errorf("第 %d 项（%s）的 operator 无效: %s", i+1, c.Name, c.Rule.Operator)
+		}
+		names[c.Name] = true
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// validOperator 判断是否为支持的比较运算符
+func validOperator(operator string) bool {
+	for _, option := range conditionOperators {
+		if option.Value == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// executeSwitch 按顺序判断各分支，mode 为 first 时只走第一个命中的分支，为 all 时走所有命中的分支
+func executeSwitch(input types.TaskInput) types.TaskOutput {
+	cases, err := SwitchCases(input["cases"])
+	if err != nil {
+		return types.NewErrorOutput("cases " + err.Error())
+	}
+
+	mode, _ := input["mode"].(string)
+	sourceData := conditionSource(input)
+
+	matched := []string{}
+	results := make([]interface{}, 0, len(cases))
+	for _, c := range cases {
+		var trace []interface{}
+		result := evaluateRule(c.Rule, "$", sourceData, &trace)
+		results = append(results, map[string]interface{}{
+			"name":    c.Name,
+			"matched": result,
+			"trace":   trace,
+		})
+		if result {
+			matched = append(matched, c.Name)
+			if mode != "all" {
+				break
+			}
+		}
+	}
+
+	branches := matched
+	message := "命中分支: " + strings.Join(matched, "、")
+	if len(matched) == 0 {
+		branches = []string{SwitchDefaultHandle}
+		message = "没有分支命中，执行 default 分支"
+	}
+
+	return types.TaskOutput{
+		Data: map[string]interface{}{
+			"matched": matched,
+			"cases":   results,
+			"message": message,
+		},
+		Branches: branches,
+	}
+}
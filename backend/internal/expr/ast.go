@@ -0,0 +1,384 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Scope 表达式可访问的变量
+type Scope map[string]interface{}
+
+// Node 语法树节点
+type Node interface {
+	Eval(scope Scope) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) Eval(scope Scope) (interface{}, error) {
+	return n.value, nil
+}
+
+// identNode 顶层变量，不存在时为 nil
+type identNode struct {
+	name string
+}
+
+func (n *identNode) Eval(scope Scope) (interface{}, error) {
+	return scope[n.name], nil
+}
+
+// indexNode 成员访问 a.b 和下标访问 a[b]，不存在的字段为 nil
+type indexNode struct {
+	target Node
+	index  Node
+}
+
+func (n *indexNode) Eval(scope Scope) (interface{}, error) {
+	target, err := n.target.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	return lookup(target, index), nil
+}
+
+type unaryNode struct {
+	op      string
+	operand Node
+}
+
+func (n *unaryNode) Eval(scope Scope) (interface{}, error) {
+	v, err := n.operand.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !Truthy(v), nil
+	}
+	f, ok := toNumber(v)
+	if !ok {
+		return nil, fmt.Errorf("无法对 %s 取负", describe(v))
+	}
+	return -f, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right Node
+}
+
+func (n *binaryNode) Eval(scope Scope) (interface{}, error) {
+	left, err := n.left.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// 逻辑运算短路求值，返回布尔值
+	switch n.op {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.Eval(scope)
+		return Truthy(right), err
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.Eval(scope)
+		return Truthy(right), err
+	}
+
+	right, err := n.right.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "+":
+		// 任一侧为字符串时拼接
+		_, ls := left.(string)
+		_, rs := right.(string)
+		if ls || rs {
+			return ToString(left) + ToString(right), nil
+		}
+	case "<", "<=", ">", ">=":
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return compare(n.op, compareStrings(l, r)), nil
+			}
+		}
+	}
+
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("运算符 %s 不支持 %s 和 %s", n.op, describe(left), describe(right))
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("除数不能为 0")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("除数不能为 0")
+		}
+		return math.Mod(l, r), nil
+	default:
+		switch {
+		case l < r:
+			return compare(n.op, -1), nil
+		case l > r:
+			return compare(n.op, 1), nil
+		default:
+			return compare(n.op, 0), nil
+		}
+	}
+}
+
+type conditionalNode struct {
+	cond, then, otherwise Node
+}
+
+func (n *conditionalNode) Eval(scope Scope) (interface{}, error) {
+	cond, err := n.cond.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	if Truthy(cond) {
+		return n.then.Eval(scope)
+	}
+	return n.otherwise.Eval(scope)
+}
+
+type callNode struct {
+	name string
+	args []Node
+}
+
+func (n *callNode) Eval(scope Scope) (interface{}, error) {
+	fn, ok := functions[n.name]
+	if !ok {
+		return nil, fmt.Errorf("未知函数: %s", n.name)
+	}
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %v", n.name, err)
+	}
+	return v, nil
+}
+
+// lookup 读取对象字段或数组元素，不存在时返回 nil
+func lookup(target, index interface{}) interface{} {
+	if target == nil {
+		return nil
+	}
+
+	switch t := target.(type) {
+	case map[string]interface{}:
+		return t[ToString(index)]
+	case []interface{}:
+		i, ok := toIndex(index, len(t))
+		if !ok {
+			return nil
+		}
+		return t[i]
+	}
+
+	v := reflect.ValueOf(target)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		elem := v.MapIndex(reflect.ValueOf(ToString(index)).Convert(v.Type().Key()))
+		if !elem.IsValid() {
+			return nil
+		}
+		return elem.Interface()
+	case reflect.Slice, reflect.Array:
+		i, ok := toIndex(index, v.Len())
+		if !ok {
+			return nil
+		}
+		return v.Index(i).Interface()
+	case reflect.Struct, reflect.Ptr:
+		// 结构体按 JSON 形式访问
+		data, err := json.Marshal(target)
+		if err != nil {
+			return nil
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return nil
+		}
+		return lookup(generic, index)
+	}
+	return nil
+}
+
+// toIndex 将下标转换为数组位置，负数表示从末尾开始
+func toIndex(index interface{}, length int) (int, bool) {
+	f, ok := toNumber(index)
+	if !ok {
+		return 0, false
+	}
+	i := int(f)
+	if i < 0 {
+		i += length
+	}
+	if i < 0 || i >= length {
+		return 0, false
+	}
+	return i, true
+}
+
+// Truthy 判断值的真假：nil、false、0、空字符串、空数组和空对象为假
+func Truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	}
+	if f, ok := toNumber(v); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	}
+	return true
+}
+
+// ToString 将值转换为字符串：nil 为空字符串，对象和数组为 JSON
+func ToString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool, int, int64, int32, float32:
+		return fmt.Sprintf("%v", t)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// toNumber 将数值类型转换为 float64，字符串不做隐式转换
+func toNumber(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case uint:
+		return float64(t), true
+	case uint64:
+		return float64(t), true
+	case uint32:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// equal 判断相等，数值按大小比较
+func equal(a, b interface{}) bool {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return x == y
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compare 根据比较结果 cmp（-1、0、1）计算比较运算符的值
+func compare(op string, cmp int) bool {
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// describe 描述值的类型，用于错误信息
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "字符串"
+	case bool:
+		return "布尔值"
+	case map[string]interface{}:
+		return "对象"
+	case []interface{}:
+		return "数组"
+	}
+	if _, ok := toNumber(v); ok {
+		return "数字"
+	}
+	return fmt.Sprintf("%T", v)
+}
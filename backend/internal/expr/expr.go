@@ -0,0 +1,224 @@
+// Package expr 实现节点配置中的 {{ }} 表达式
+//
+// 表达式支持字面量、成员和下标访问、算术/比较/逻辑运算、三元运算和辅助函数，例如：
+//
+//	{{ nodes.fetch.data.body.id }}
+//	{{ upper(trigger.body.name) + "!" }}
+//	{{ formatDate(now(), "YYYY-MM-DD", "Asia/Shanghai") }}
+package expr
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"workflow-engine/internal/types"
+)
+
+// EnvPrefix 以该前缀开头的环境变量作为常量暴露给表达式（env.NAME 对应 WORKFLOW_VAR_NAME）
+const EnvPrefix = "WORKFLOW_VAR_"
+
+// Env 读取表达式可用的环境常量
+func Env() map[string]interface{} {
+	env := make(map[string]interface{})
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, EnvPrefix) {
+			continue
+		}
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[len(EnvPrefix):i]] = kv[i+1:]
+		}
+	}
+	return env
+}
+
+// HasTemplate 判断字符串中是否包含表达式
+func HasTemplate(s string) bool {
+	i := strings.Index(s, "{{")
+	return i >= 0 && strings.Contains(s[i:], "}}")
+}
+
+// Template 求值字符串中的所有 {{ }} 表达式
+// 整个字符串只有一个表达式时保留其原始类型（数字、对象等），否则将结果拼接为字符串
+func Template(s string, scope Scope) (interface{}, error) {
+	var sb strings.Builder
+	rest := s
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start+2:], "}}")
+		if end < 0 {
+			break
+		}
+		end += start + 2
+
+		node, err := Parse(strings.TrimSpace(rest[start+2 : end]))
+		if err != nil {
+			return nil, fmt.Errorf("表达式 %q 解析失败: %v", rest[start:end+2], err)
+		}
+		value, err := node.Eval(scope)
+		if err != nil {
+			return nil, fmt.Errorf("表达式 %q 求值失败: %v", rest[start:end+2], err)
+		}
+
+		if rest == s && start == 0 && end+2 == len(s) {
+			return value, nil
+		}
+		sb.WriteString(rest[:start])
+		sb.WriteString(ToString(value))
+		rest = rest[end+2:]
+	}
+	sb.WriteString(rest)
+	return sb.String(), nil
+}
+
+// Evaluate 求值单个表达式，可省略外层的 {{ }}
+func Evaluate(src string, scope Scope) (interface{}, error) {
+	if HasTemplate(src) {
+		return Template(src, scope)
+	}
+	node, err := Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("表达式 %q 解析失败: %v", src, err)
+	}
+	value, err := node.Eval(scope)
+	if err != nil {
+		return nil, fmt.Errorf("表达式 %q 求值失败: %v", src, err)
+	}
+	return value, nil
+}
+
+// Resolve 递归求值字符串、对象和数组中的表达式，其他类型原样返回
+func Resolve(value interface{}, scope Scope) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !HasTemplate(v) {
+			return v, nil
+		}
+		return Template(v, scope)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved, err := Resolve(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = resolved
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := Resolve(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// Check 检查字符串、对象和数组中所有表达式的语法和函数名，不求值
+func Check(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		rest := v
+		for {
+			start := strings.Index(rest, "{{")
+			if start < 0 {
+				return nil
+			}
+			end := strings.Index(rest[start+2:], "}}")
+			if end < 0 {
+				return nil
+			}
+			end += start + 2
+			if err := checkSource(strings.TrimSpace(rest[start+2:end]), rest[start:end+2]); err != nil {
+				return err
+			}
+			rest = rest[end+2:]
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if err := Check(item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := Check(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CheckExpression 检查单个表达式的语法和函数名，可省略外层的 {{ }}
+func CheckExpression(src string) error {
+	if HasTemplate(src) {
+		return Check(src)
+	}
+	return checkSource(src, src)
+}
+
+// checkSource 解析表达式并检查调用的函数均已定义，label 为错误信息中展示的原文
+func checkSource(src, label string) error {
+	node, err := Parse(src)
+	if err != nil {
+		return fmt.Errorf("表达式 %q 解析失败: %v", label, err)
+	}
+	if err := checkCalls(node); err != nil {
+		return fmt.Errorf("表达式 %q 无效: %v", label, err)
+	}
+	return nil
+}
+
+// checkCalls 递归检查语法树中调用的函数均已定义
+func checkCalls(node Node) error {
+	var children []Node
+	switch n := node.(type) {
+	case *indexNode:
+		children = []Node{n.target, n.index}
+	case *unaryNode:
+		children = []Node{n.operand}
+	case *binaryNode:
+		children = []Node{n.left, n.right}
+	case *conditionalNode:
+		children = []Node{n.cond, n.then, n.otherwise}
+	case *callNode:
+		if _, ok := functions[n.name]; !ok {
+			return fmt.Errorf("未知函数: %s", n.name)
+		}
+		children = n.args
+	}
+	for _, child := range children {
+		if err := checkCalls(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveInput 求值任务输入中各字段的表达式，错误信息包含字段名
+func ResolveInput(input types.TaskInput, scope Scope) (types.TaskInput, error) {
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(types.TaskInput, len(input))
+	for _, key := range keys {
+		resolved, err := Resolve(input[key], scope)
+		if err != nil {
+			return nil, fmt.Errorf("字段 %s: %v", key, err)
+		}
+		result[key] = resolved
+	}
+	return result, nil
+}
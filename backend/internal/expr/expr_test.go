@@ -0,0 +1,195 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testScope 测试用的表达式变量
+func testScope() Scope {
+	return Scope{
+		"nodes": map[string]interface{}{
+			"fetch": map[string]interface{}{
+				"data": map[string]interface{}{
+					"body":       map[string]interface{}{"id": 42.0, "tags": []interface{}{"a", "b"}},
+					"statusCode": 200.0,
+				},
+			},
+		},
+		"trigger": map[string]interface{}{"body": map[string]interface{}{"name": "bob"}},
+		"typed":   map[string]int{"n": 1},
+		"list":    []string{"x", "y"},
+		"run": struct {
+			RunID string `json:"runId"`
+		}{RunID: "r1"},
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{"plain text", "plain text"},
+		{"{{ nodes.fetch.data.body.id }}", 42.0},
+		{"{{nodes.fetch.data.body}}", map[string]interface{}{"id": 42.0, "tags": []interface{}{"a", "b"}}},
+		{"id={{ nodes.fetch.data.body.id + 1 }}", "id=43"},
+		{"{{ trigger.body.name }}/{{ nodes.fetch.data.body.tags[-1] }}", "bob/b"},
+		{"tags: {{ nodes.fetch.data.body.tags }}", `tags: ["a","b"]`},
+		{"{{ nodes.fetch.data.statusCode >= 200 && nodes.fetch.data.statusCode < 300 ? 'ok' : 'bad' }}", "ok"},
+		{"unclosed {{ 1 + 1", "unclosed {{ 1 + 1"},
+		{" {{ 1 }}", " 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := Template(tt.src, testScope())
+			if err != nil {
+				t.Fatalf("Template: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("= %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingMembers(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{"missing", nil},
+		{"missing.field", nil},
+		{"nodes.other.data.body", nil},
+		{"nodes.fetch.data.body.tags[5]", nil},
+		{"nodes.fetch.data.body.tags[-3]", nil},
+		{"nodes.fetch.data.body.tags.name", nil},
+		{"trigger.body.name.first", nil},
+		{"missing == null", true},
+		{"!missing", true},
+		{"missing ? 1 : 2", 2.0},
+		{"'[' + missing + ']'", "[]"},
+		{"typed.n", 1},
+		{"typed.m", nil},
+		{"list[1]", "y"},
+		{"run.runId", "r1"},
+		{"run.other", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := evalString(tt.src, testScope())
+			if err != nil {
+				t.Fatalf("evalString: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("= %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"{{ 1 + }}", `表达式 "{{ 1 + }}" 解析失败: 表达式不完整`},
+		{"x {{ 'a' - 1 }}", `表达式 "{{ 'a' - 1 }}" 求值失败: 运算符 - 不支持 字符串 和 数字`},
+		{"{{ missing * 2 }}", `表达式 "{{ missing * 2 }}" 求值失败: 运算符 * 不支持 null 和 数字`},
+		{"{{ -'a' }}", `表达式 "{{ -'a' }}" 求值失败: 无法对 字符串 取负`},
+		{"{{ 1 / 0 }}", `表达式 "{{ 1 / 0 }}" 求值失败: 除数不能为 0`},
+		{"{{ 5 % 0 }}", `表达式 "{{ 5 % 0 }}" 求值失败: 除数不能为 0`},
+		{"{{ foo(1) }}", `表达式 "{{ foo(1) }}" 求值失败: 未知函数: foo`},
+		{"{{ upper() }}", `表达式 "{{ upper() }}" 求值失败: upper(): 需要 1 个参数，实际为 0 个`},
+		{"{{ 1 }} {{ bad( }}", `表达式 "{{ bad( }}" 解析失败: 表达式不完整`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Template(tt.src, testScope())
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Template(%q) error = %v, want %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	value := map[string]interface{}{
+		"url":   "https://api/{{ trigger.body.name }}",
+		"id":    "{{ nodes.fetch.data.body.id }}",
+		"list":  []interface{}{"{{ 1 + 1 }}", "x", 3.0},
+		"plain": true,
+	}
+	want := map[string]interface{}{
+		"url":   "https://api/bob",
+		"id":    42.0,
+		"list":  []interface{}{2.0, "x", 3.0},
+		"plain": true,
+	}
+	got, err := Resolve(value, testScope())
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve = %#v, want %#v", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"没有表达式", "plain", ""},
+		{"合法的表达式", "{{ upper(trigger.body.name) }} {{ a.b[0] }}", ""},
+		{"嵌套的函数调用", "{{ round(max(1, number('2')), 2) }}", ""},
+		{"语法错误", "x {{ 1 + }}", `表达式 "{{ 1 + }}" 解析失败: 表达式不完整`},
+		{"未知函数", "{{ uper(name) }}", `表达式 "{{ uper(name) }}" 无效: 未知函数: uper`},
+		{"参数中的未知函数", "{{ upper(foo(1)) }}", `表达式 "{{ upper(foo(1)) }}" 无效: 未知函数: foo`},
+		{"下标中的未知函数", "{{ a[foo()] }}", `表达式 "{{ a[foo()] }}" 无效: 未知函数: foo`},
+		{"三元运算中的未知函数", "{{ a ? 1 : -foo() }}", `表达式 "{{ a ? 1 : -foo() }}" 无效: 未知函数: foo`},
+		{"对象中的表达式", map[string]interface{}{"a": []interface{}{"{{ bar() }}"}}, `表达式 "{{ bar() }}" 无效: 未知函数: bar`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.value)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Check error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Check error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckExpression(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"a.b > 1 && contains(tags, 'x')", ""},
+		{"{{ a.b }}", ""},
+		{"a +", `表达式 "a +" 解析失败: 表达式不完整`},
+		{"a == uper(b)", `表达式 "a == uper(b)" 无效: 未知函数: uper`},
+		{"{{ uper(b) }}", `表达式 "{{ uper(b) }}" 无效: 未知函数: uper`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			err := CheckExpression(tt.src)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("CheckExpression error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("CheckExpression error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
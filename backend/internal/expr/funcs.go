@@ -0,0 +1,435 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// function 表达式中可调用的辅助函数
+type function func(args []interface{}) (interface{}, error)
+
+// functions 内置辅助函数
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		// 字符串
+		"upper":      stringFunc(strings.ToUpper),
+		"lower":      stringFunc(strings.ToLower),
+		"trim":       stringFunc(strings.TrimSpace),
+		"string":     fnString,
+		"replace":    fnReplace,
+		"split":      fnSplit,
+		"join":       fnJoin,
+		"concat":     fnConcat,
+		"substr":     fnSubstr,
+		"contains":   fnContains,
+		"startsWith": stringPredicate(strings.HasPrefix),
+		"endsWith":   stringPredicate(strings.HasSuffix),
+		"len":        fnLen,
+
+		// 数学
+		"number": fnNumber,
+		"abs":    mathFunc(math.Abs),
+		"floor":  mathFunc(math.Floor),
+		"ceil":   mathFunc(math.Ceil),
+		"round":  fnRound,
+		"min":    fnMin,
+		"max":    fnMax,
+
+		// 日期
+		"now":        fnNow,
+		"formatDate": fnFormatDate,
+		"addDate":    fnAddDate,
+		"unix":       fnUnix,
+
+		// JSON
+		"json":      fnJSON,
+		"parseJson": fnParseJSON,
+
+		// 其他
+		"default": fnDefault,
+	}
+}
+
+// arity 检查参数个数
+func arity(args []interface{}, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		switch {
+		case min == max:
+			return fmt.Errorf("需要 %d 个参数，实际为 %d 个", min, len(args))
+		case max < 0:
+			return fmt.Errorf("至少需要 %d 个参数，实际为 %d 个", min, len(args))
+		default:
+			return fmt.Errorf("需要 %d-%d 个参数，实际为 %d 个", min, max, len(args))
+		}
+	}
+	return nil
+}
+
+func stringFunc(f func(string) string) function {
+	return func(args []interface{}) (interface{}, error) {
+		if err := arity(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return f(ToString(args[0])), nil
+	}
+}
+
+func stringPredicate(f func(s, part string) bool) function {
+	return func(args []interface{}) (interface{}, error) {
+		if err := arity(args, 2, 2); err != nil {
+			return nil, err
+		}
+		return f(ToString(args[0]), ToString(args[1])), nil
+	}
+}
+
+func mathFunc(f func(float64) float64) function {
+	return func(args []interface{}) (interface{}, error) {
+		if err := arity(args, 1, 1); err != nil {
+			return nil, err
+		}
+		x, err := numberArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(x), nil
+	}
+}
+
+// numberArg 将参数转换为数字，数字字符串同样接受
+func numberArg(v interface{}) (float64, error) {
+	if f, ok := toNumber(v); ok {
+		return f, nil
+	}
+	if s, ok := v.(string); ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("%s 不是数字", describe(v))
+}
+
+func fnString(args []interface{}) (interface{}, error) {
+	if err := arity(args, 1, 1); err != nil {
+		return nil, err
+	}
+	return ToString(args[0]), nil
+}
+
+func fnReplace(args []interface{}) (interface{}, error) {
+	if err := arity(args, 3, 3); err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(ToString(args[0]), ToString(args[1]), ToString(args[2])), nil
+}
+
+func fnSplit(args []interface{}) (interface{}, error) {
+	if err := arity(args, 2, 2); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(ToString(args[0]), ToString(args[1]))
+	result := make([]interface{}, len(parts))
+	for i, part := range parts {
+		result[i] = part
+	}
+	return result, nil
+}
+
+func fnJoin(args []interface{}) (interface{}, error) {
+	if err := arity(args, 2, 2); err != nil {
+		return nil, err
+	}
+	items, ok := toList(args[0])
+	if !ok {
+		return nil, fmt.Errorf("第一个参数需为数组")
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = ToString(item)
+	}
+	return strings.Join(parts, ToString(args[1])), nil
+}
+
+func fnConcat(args []interface{}) (interface{}, error) {
+	var sb strings.Builder
+	for _, arg := range args {
+		sb.WriteString(ToString(arg))
+	}
+	return sb.String(), nil
+}
+
+// fnSubstr substr(s, start, length?)，按字符计算
+func fnSubstr(args []interface{}) (interface{}, error) {
+	if err := arity(args, 2, 3); err != nil {
+		return nil, err
+	}
+	runes := []rune(ToString(args[0]))
+	start, err := numberArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	from := clamp(int(start), len(runes))
+	to := len(runes)
+	if len(args) == 3 {
+		length, err := numberArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		to = clamp(from+int(length), len(runes))
+	}
+	if to < from {
+		return "", nil
+	}
+	return string(runes[from:to]), nil
+}
+
+// fnContains contains(字符串或数组, 值)
+func fnContains(args []interface{}) (interface{}, error) {
+	if err := arity(args, 2, 2); err != nil {
+		return nil, err
+	}
+	if items, ok := toList(args[0]); ok {
+		for _, item := range items {
+			if equal(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return strings.Contains(ToString(args[0]), ToString(args[1])), nil
+}
+
+func fnLen(args []interface{}) (interface{}, error) {
+	if err := arity(args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return float64(0), nil
+	case string:
+		return float64(len([]rune(v))), nil
+	}
+	rv := reflect.ValueOf(args[0])
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return float64(rv.Len()), nil
+	}
+	return nil, fmt.Errorf("%s 没有长度", describe(args[0]))
+}
+
+func fnNumber(args []interface{}) (interface{}, error) {
+	if err := arity(args, 1, 1); err != nil {
+		return nil, err
+	}
+	if b, ok := args[0].(bool); ok {
+		if b {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	}
+	return numberArg(args[0])
+}
+
+// fnRound round(x, digits?)
+func fnRound(args []interface{}) (interface{}, error) {
+	if err := arity(args, 1, 2); err != nil {
+		return nil, err
+	}
+	x, err := numberArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	digits := 0.0
+	if len(args) == 2 {
+		if digits, err = numberArg(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	scale := math.Pow(10, math.Trunc(digits))
+	return math.Round(x*scale) / scale, nil
+}
+
+func fnMin(args []interface{}) (interface{}, error) {
+	return extreme(args, func(a, b float64) bool { return a < b })
+}
+
+func fnMax(args []interface{}) (interface{}, error) {
+	return extreme(args, func(a, b float64) bool { return a > b })
+}
+
+// extreme 求参数（或单个数组参数）中的最值
+func extreme(args []interface{}, better func(a, b float64) bool) (interface{}, error) {
+	if len(args) == 1 {
+		if items, ok := toList(args[0]); ok {
+			args = items
+		}
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("至少需要 1 个参数")
+	}
+	result, err := numberArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args[1:] {
+		x, err := numberArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		if better(x, result) {
+			result = x
+		}
+	}
+	return result, nil
+}
+
+// fnNow 当前时间（RFC3339）
+func fnNow(args []interface{}) (interface{}, error) {
+	if err := arity(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return time.Now().Format(time.RFC3339), nil
+}
+
+// fnFormatDate formatDate(时间, 格式, 时区?)
+// 格式使用 YYYY、MM、DD、HH、mm、ss 占位符，时间可以是 RFC3339 字符串或 Unix 秒
+func fnFormatDate(args []interface{}) (interface{}, error) {
+	if err := arity(args, 2, 3); err != nil {
+		return nil, err
+	}
+	t, err := dateArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 3 {
+		loc, err := time.LoadLocation(ToString(args[2]))
+		if err != nil {
+			return nil, fmt.Errorf("时区无效: %s", ToString(args[2]))
+		}
+		t = t.In(loc)
+	}
+	return t.Format(dateLayout.Replace(ToString(args[1]))), nil
+}
+
+// dateLayout 日期格式占位符到 Go 时间格式的转换
+var dateLayout = strings.NewReplacer(
+	"YYYY", "2006",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+// fnAddDate addDate(时间, 时长)，时长如 "1h30m"、"-24h"，返回 RFC3339
+func fnAddDate(args []interface{}) (interface{}, error) {
+	if err := arity(args, 2, 2); err != nil {
+		return nil, err
+	}
+	t, err := dateArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	d, err := time.ParseDuration(ToString(args[1]))
+	if err != nil {
+		return nil, fmt.Errorf("时长格式错误: %s", ToString(args[1]))
+	}
+	return t.Add(d).Format(time.RFC3339), nil
+}
+
+// fnUnix unix(时间?)，返回 Unix 秒，省略参数时为当前时间
+func fnUnix(args []interface{}) (interface{}, error) {
+	if err := arity(args, 0, 1); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return float64(time.Now().Unix()), nil
+	}
+	t, err := dateArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return float64(t.Unix()), nil
+}
+
+// dateArg 将参数转换为时间：RFC3339 字符串或 Unix 秒
+func dateArg(v interface{}) (time.Time, error) {
+	if f, ok := toNumber(v); ok {
+		return time.Unix(int64(f), 0), nil
+	}
+	s := ToString(v)
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("时间格式错误（需为 RFC3339 或 Unix 秒）: %s", s)
+	}
+	return t, nil
+}
+
+func fnJSON(args []interface{}) (interface{}, error) {
+	if err := arity(args, 1, 1); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func fnParseJSON(args []interface{}) (interface{}, error) {
+	if err := arity(args, 1, 1); err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(ToString(args[0])), &v); err != nil {
+		return nil, fmt.Errorf("JSON 格式错误: %v", err)
+	}
+	return v, nil
+}
+
+// fnDefault default(值, 默认值)，值为 nil 或空字符串时返回默认值
+func fnDefault(args []interface{}) (interface{}, error) {
+	if err := arity(args, 2, 2); err != nil {
+		return nil, err
+	}
+	if args[0] == nil || args[0] == "" {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+// toList 将数组类型的值转换为 []interface{}
+func toList(v interface{}) ([]interface{}, bool) {
+	if list, ok := v.([]interface{}); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+func clamp(i, length int) int {
+	if i < 0 {
+		i += length
+		if i < 0 {
+			i = 0
+		}
+	}
+	if i > length {
+		i = length
+	}
+	return i
+}
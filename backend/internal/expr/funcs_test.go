@@ -0,0 +1,139 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFunctions(t *testing.T) {
+	scope := Scope{
+		"items": []interface{}{"a", "b", "c"},
+		"nums":  []interface{}{3.0, 1.0, 2.0},
+		"obj":   map[string]interface{}{"a": 1.0},
+		"empty": "",
+	}
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		// 字符串
+		{"upper('abc')", "ABC"},
+		{"lower('AbC')", "abc"},
+		{"trim('  a b ')", "a b"},
+		{"string(1.5)", "1.5"},
+		{"string(obj)", `{"a":1}`},
+		{"string(missing)", ""},
+		{"replace('a-b-c', '-', '+')", "a+b+c"},
+		{"split('a,b', ',')", []interface{}{"a", "b"}},
+		{"join(items, '/')", "a/b/c"},
+		{"concat('a', 1, true, missing)", "a1true"},
+		{"substr('hello', 1, 3)", "ell"},
+		{"substr('hello', -3)", "llo"},
+		{"substr('你好世界', 2)", "世界"},
+		{"substr('hello', 3, 10)", "lo"},
+		{"contains('hello', 'ell')", true},
+		{"contains(items, 'b')", true},
+		{"contains(nums, 2)", true},
+		{"contains(items, 'z')", false},
+		{"startsWith('hello', 'he')", true},
+		{"endsWith('hello', 'he')", false},
+		{"len('你好')", 2.0},
+		{"len(items)", 3.0},
+		{"len(obj)", 1.0},
+		{"len(missing)", 0.0},
+
+		// 数学
+		{"number('12.5')", 12.5},
+		{"number(' 3 ')", 3.0},
+		{"number(true)", 1.0},
+		{"abs(-2)", 2.0},
+		{"floor(1.7)", 1.0},
+		{"ceil('1.2')", 2.0},
+		{"round(2.5)", 3.0},
+		{"round(10 / 3, 2)", 3.33},
+		{"min(3, 1, 2)", 1.0},
+		{"max(nums)", 3.0},
+
+		// 日期
+		{"formatDate('2024-01-02T03:04:05Z', 'YYYY-MM-DD HH:mm:ss')", "2024-01-02 03:04:05"},
+		{"formatDate(0, 'YYYY-MM-DD HH:mm', 'Asia/Shanghai')", "1970-01-01 08:00"},
+		{"addDate('2024-01-01T00:00:00Z', '-24h')", "2023-12-31T00:00:00Z"},
+		{"addDate('2024-01-01T00:00:00+08:00', '1h30m')", "2024-01-01T01:30:00+08:00"},
+		{"unix('1970-01-01T00:01:00Z')", 60.0},
+
+		// JSON
+		{"json(obj)", `{"a":1}`},
+		{"json(items)", `["a","b","c"]`},
+		{`parseJson('{"a":[1,2]}').a[1]`, 2.0},
+
+		// 其他
+		{"default(missing, 'd')", "d"},
+		{"default(empty, 'd')", "d"},
+		{"default(0, 'd')", 0.0},
+		{"default(false, 'd')", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := evalString(tt.src, scope)
+			if err != nil {
+				t.Fatalf("evalString: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("= %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionsNow(t *testing.T) {
+	before := time.Now().Truncate(time.Second)
+	got, err := evalString("now()", Scope{})
+	if err != nil {
+		t.Fatalf("evalString: %v", err)
+	}
+	now, err := time.Parse(time.RFC3339, got.(string))
+	if err != nil {
+		t.Fatalf("now() = %q, want RFC3339: %v", got, err)
+	}
+	if now.Before(before) || now.After(time.Now()) {
+		t.Errorf("now() = %s, want current time", got)
+	}
+
+	got, err = evalString("unix() - unix(now()) <= 1", Scope{})
+	if err != nil || got != true {
+		t.Errorf("unix() = %v, %v, want current Unix seconds", got, err)
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"upper('a', 'b')", "upper(): 需要 1 个参数，实际为 2 个"},
+		{"substr('a')", "substr(): 需要 2-3 个参数，实际为 1 个"},
+		{"min()", "min(): 至少需要 1 个参数"},
+		{"now(1)", "now(): 需要 0 个参数，实际为 1 个"},
+		{"join('a', ',')", "join(): 第一个参数需为数组"},
+		{"abs('x')", "abs(): 字符串 不是数字"},
+		{"max(1, null)", "max(): null 不是数字"},
+		{"len(true)", "len(): 布尔值 没有长度"},
+		{"formatDate('yesterday', 'YYYY')", "formatDate(): 时间格式错误（需为 RFC3339 或 Unix 秒）: yesterday"},
+		{"formatDate(0, 'YYYY', 'Mars/Base')", "formatDate(): 时区无效: Mars/Base"},
+		{"addDate(0, 'soon')", "addDate(): 时长格式错误: soon"},
+		{"parseJson('{')", "parseJson(): JSON 格式错误: unexpected end of JSON input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			node, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			_, err = node.Eval(Scope{})
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Eval(%q) error = %v, want %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
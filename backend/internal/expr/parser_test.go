@@ -0,0 +1,137 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+// evalString 解析并求值单个表达式
+func evalString(src string, scope Scope) (interface{}, error) {
+	node, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return node.Eval(scope)
+}
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"12 / 3 / 2", 2.0},
+		{"7 % 4 * 2", 6.0},
+		{"-2 * 3", -6.0},
+		{"--2", 2.0},
+		{"-(1 + 2)", -3.0},
+		{"1 + 2 < 4", true},
+		{"1 < 2 == 2 < 3", true},
+		{"1 == 1 && 2 == 3", false},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!false && true", true},
+		{"!(1 == 1)", false},
+		{"!0", true},
+		{"1 < 2 ? 'a' : 'b'", "a"},
+		{"false ? 1 : true ? 2 : 3", 2.0},
+		{"true ? false ? 1 : 2 : 3", 2.0},
+		{"1 + 1 == 2 ? 'yes' : 'no'", "yes"},
+		{"'a' + 1 + 2", "a12"},
+		{"1 + 2 + 'a'", "3a"},
+		{"1.5 + 2.25", 3.75},
+		{`"x\"y" + 'it\'s' + "\n"`, "x\"yit's\n"},
+		{"null == nil", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			node, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, err := node.Eval(Scope{})
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("= %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMemberAccess(t *testing.T) {
+	scope := Scope{
+		"nodes": map[string]interface{}{
+			"fetch": map[string]interface{}{
+				"data": map[string]interface{}{
+					"items": []interface{}{"a", "b", map[string]interface{}{"id": 3.0}},
+					"count": 2.0,
+				},
+			},
+		},
+		"key": "fetch",
+		"订单":  map[string]interface{}{"金额": 12.5},
+	}
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{"nodes.fetch.data.count", 2.0},
+		{"nodes['fetch'].data.count", 2.0},
+		{"nodes[key].data.count", 2.0},
+		{"nodes.fetch.data.items.0", "a"},
+		{"nodes.fetch.data.items[1]", "b"},
+		{"nodes.fetch.data.items.2.id", 3.0},
+		{"nodes.fetch.data.items[-1].id", 3.0},
+		{"nodes.fetch.data.items[nodes.fetch.data.count - 1]", "b"},
+		{"nodes.fetch.data.count.5", nil},
+		{"订单.金额", 12.5},
+		{"订单['金额'] * 2", 25.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := evalString(tt.src, scope)
+			if err != nil {
+				t.Fatalf("evalString: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("= %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"", "表达式不完整"},
+		{"1 +", "表达式不完整"},
+		{"(1 + 2", `缺少 ")"`},
+		{"a[1", `缺少 "]"`},
+		{"f(1, 2", `缺少 ")"`},
+		{"f(1 2)", `缺少 ")"（位置 4，实际为 "2"）`},
+		{"true ? 1", `缺少 ":"`},
+		{"1 2", `多余的内容 "2"（位置 2）`},
+		{"a.", `"." 后需要字段名（位置 2）`},
+		{"a.(b)", `"." 后需要字段名（位置 2）`},
+		{"* 2", `意外的 "*"（位置 0）`},
+		{"'abc", "字符串未闭合（位置 0）"},
+		{"1 # 2", `无法识别的字符 '#'（位置 2）`},
+		{"a = 1", `无法识别的字符 '='（位置 2）`},
+		{"1 ＋ 2", `无法识别的字符 '＋'（位置 2）`},
+		{"a\xff", `无法识别的字符 '�'（位置 1）`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
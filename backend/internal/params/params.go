@@ -0,0 +1,121 @@
+// Package params 按 ParamConfig 声明校验参数值
+package params
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"workflow-engine/internal/types"
+)
+
+// Coerce 按参数类型校验并转换取值
+// number 和 boolean 接受对应格式的字符串，select 的取值必须是选项之一，json 接受任意值，其他类型不接受对象和数组
+func Coerce(param types.ParamConfig, value interface{}) (interface{}, error) {
+	switch param.Type {
+	case "number":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("需为数字，实际为 %q", v)
+			}
+			return f, nil
+		}
+		return nil, fmt.Errorf("需为数字，实际为 %s", describe(value))
+
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("需为布尔值，实际为 %q", v)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("需为布尔值，实际为 %s", describe(value))
+
+	case "select":
+		for _, option := range param.Options {
+			if fmt.Sprintf("%v", option.Value) == fmt.Sprintf("%v", value) {
+				return option.Value, nil
+			}
+		}
+		allowed := make([]string, len(param.Options))
+		for i, option := range param.Options {
+			allowed[i] = fmt.Sprintf("%v", option.Value)
+		}
+		return nil, fmt.Errorf("取值 %v 不在可选项中（%s）", value, strings.Join(allowed, "、"))
+
+	case "json":
+		return value, nil
+
+	default:
+		// 文本类参数接受字符串和其他标量，对象和数组视为类型错误
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("需为字符串，实际为 %s", describe(value))
+		}
+		return value, nil
+	}
+}
+
+// describe 描述取值的类型，用于错误信息
+func describe(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "字符串"
+	case bool:
+		return "布尔值"
+	case float64, int, int64:
+		return "数字"
+	case map[string]interface{}:
+		return "对象"
+	case []interface{}:
+		return "数组"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Missing 判断取值是否视为未填写
+func Missing(value interface{}) bool {
+	return value == nil || value == ""
+}
+
+// Resolve 按声明校验调用方提供的参数并填充默认值
+// 未声明的参数被忽略；所有问题合并在一个错误中返回
+func Resolve(declared []types.ParamConfig, supplied map[string]interface{}) (types.TaskInput, error) {
+	result := make(types.TaskInput, len(declared))
+	var problems []string
+	for _, param := range declared {
+		value := supplied[param.Name]
+		if Missing(value) {
+			if param.Default != nil {
+				result[param.Name] = param.Default
+			} else if param.Required {
+				problems = append(problems, "缺少必填参数: "+param.Name)
+			}
+			continue
+		}
+
+		v, err := Coerce(param, value)
+		if err != nil {
+			problems = append(problems, param.Name+": "+err.Error())
+			continue
+		}
+		result[param.Name] = v
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return result, nil
+}
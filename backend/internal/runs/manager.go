@@ -0,0 +1,206 @@
+package runs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	"workflow-engine/internal/engine"
+	"workflow-engine/internal/store"
+	"workflow-engine/internal/types"
+
+	"github.com/google/uuid"
+)
+
+// ErrQueueFull 等待队列已满
+var ErrQueueFull = errors.New("运行队列已满，请稍后重试")
+
+// Spec 运行规格
+type Spec struct {
+	Workflow   types.Workflow
+	WorkflowID string // 执行已保存的工作流时记录其 ID，临时工作流为空
+	Version    int
+	Inputs     map[string]interface{} // 调用方提供的工作流输入
+	Trigger    types.TaskInput        // 触发数据（如 webhook 请求），注入到起始节点的输入中
+}
+
+// Manager 运行管理器
+// 负责创建运行、在后台工作池中执行、保存执行历史，并向订阅者广播运行事件
+type Manager struct {
+	store store.Store
+	queue chan *Run
+
+	mu     sync.RWMutex
+	active map[string]*Run // 未结束的运行
+}
+
+// NewManager 创建运行管理器并启动 workers 个后台工作协程
+func NewManager(st store.Store, workers, queueSize int) *Manager {
+	if workers <= 0 {
+		workers = 1
+	}
+	m := &Manager{
+		store:  st,
+		queue:  make(chan *Run, queueSize),
+		active: make(map[string]*Run),
+	}
+	for i := 0; i < workers; i++ {
+		go m.worker()
+	}
+	return m
+}
+
+// Submit 提交运行到后台队列，立即返回
+func (m *Manager) Submit(spec Spec) (*Run, error) {
+	r := m.create(context.Background(), spec, "queued")
+	select {
+	case m.queue <- r:
+		return r, nil
+	default:
+		// 被拒绝的运行同样以失败状态记录在执行历史中
+		r.cancel()
+		m.finish(r, types.WorkflowExecutionResult{
+			Status:    "error",
+			StartTime: time.Now().Format(time.RFC3339),
+			EndTime:   time.Now().Format(time.RFC3339),
+			Logs:      []types.NodeExecutionLog{},
+			Error:     ErrQueueFull.Error(),
+		})
+		return nil, ErrQueueFull
+	}
+}
+
+// Execute 在当前协程中同步执行运行，ctx 被取消时运行随之取消
+// emit 接收与订阅者相同的事件，可为 nil
+func (m *Manager) Execute(ctx context.Context, spec Spec, emit EventHandler) types.WorkflowExecutionResult {
+	r := m.create(ctx, spec, "running")
+	r.direct = emit
+	return m.execute(r)
+}
+
+// StoredSpec 根据已保存的工作流构建运行规格，version 为 0 时使用当前版本
+// 工作流或版本不存在时返回 store.ErrNotFound
+func (m *Manager) StoredSpec(workflowID string, version int) (Spec, error) {
+	def, err := m.store.GetWorkflow(workflowID)
+	if err != nil {
+		return Spec{}, err
+	}
+	spec := Spec{Workflow: def.Workflow, WorkflowID: def.ID, Version: def.Version}
+
+	if version != 0 && version != def.Version {
+		v, err := m.store.GetVersion(def.ID, version)
+		if err != nil {
+			return Spec{}, err
+		}
+		spec.Workflow, spec.Version = v.Workflow, v.Version
+	}
+	return spec, nil
+}
+
+// Get 获取未结束的运行
+func (m *Manager) Get(runID string) (*Run, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.active[runID]
+	return r, ok
+}
+
+// Cancel 取消未结束的运行
+func (m *Manager) Cancel(runID string) bool {
+	r, ok := m.Get(runID)
+	if !ok {
+		return false
+	}
+	r.cancel()
+	return true
+}
+
+// worker 后台工作协程，依次执行队列中的运行
+func (m *Manager) worker() {
+	for r := range m.queue {
+		// 排队期间被取消的运行直接结束
+		if r.ctx.Err() != nil {
+			m.finish(r, types.WorkflowExecutionResult{
+				Status:    "cancelled",
+				StartTime: r.record.StartTime,
+				EndTime:   time.Now().Format(time.RFC3339),
+				Logs:      []types.NodeExecutionLog{},
+				Error:     "工作流执行已取消",
+			})
+			continue
+		}
+		m.execute(r)
+	}
+}
+
+// create 创建运行并保存初始记录
+func (m *Manager) create(ctx context.Context, spec Spec, status string) *Run {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &Run{
+		ID:       uuid.Must(uuid.NewV7()).String(),
+		spec:     spec,
+		ctx:      ctx,
+		cancel:   cancel,
+		finished: make(chan struct{}),
+		subs:     make(map[chan types.RunEvent]struct{}),
+		record: types.WorkflowExecutionResult{
+			Status:     status,
+			StartTime:  time.Now().Format(time.RFC3339),
+			Logs:       []types.NodeExecutionLog{},
+			WorkflowID: spec.WorkflowID,
+			Version:    spec.Version,
+		},
+	}
+	r.record.RunID = r.ID
+
+	m.mu.Lock()
+	m.active[r.ID] = r
+	m.mu.Unlock()
+
+	m.save(r.record)
+	return r
+}
+
+// execute 执行运行并保存结果
+func (m *Manager) execute(r *Run) types.WorkflowExecutionResult {
+	if r.record.Status != "running" {
+		r.record.Status = "running"
+		r.record.StartTime = time.Now().Format(time.RFC3339)
+		m.save(r.record)
+	}
+
+	// 已保存的工作流记入调用链，子工作流节点据此检测递归调用
+	ctx := r.ctx
+	if r.spec.WorkflowID != "" {
+		ctx = engine.WithWorkflowID(ctx, r.spec.WorkflowID)
+	}
+	result := engine.Run(ctx, r.spec.Workflow, r.spec.Inputs, r.spec.Trigger, r.publish)
+	return m.finish(r, result)
+}
+
+// finish 保存最终结果，推送 complete 事件并关闭所有订阅
+func (m *Manager) finish(r *Run, result types.WorkflowExecutionResult) types.WorkflowExecutionResult {
+	result.RunID = r.ID
+	result.WorkflowID = r.spec.WorkflowID
+	result.Version = r.spec.Version
+	m.save(result)
+
+	events := r.complete(result)
+	r.cancel()
+	if err := m.store.SaveRunEvents(r.ID, events); err != nil {
+		log.Printf("保存运行事件失败 (%s): %v", r.ID, err)
+	}
+
+	m.mu.Lock()
+	delete(m.active, r.ID)
+	m.mu.Unlock()
+	return result
+}
+
+// save 保存运行记录，失败时仅记录日志，不影响执行
+func (m *Manager) save(run types.WorkflowExecutionResult) {
+	if err := m.store.SaveRun(run); err != nil {
+		log.Printf("保存运行记录失败 (%s): %v", run.RunID, err)
+	}
+}
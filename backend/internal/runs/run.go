@@ -0,0 +1,103 @@
+package runs
+
+import (
+	"context"
+	"sync"
+	"workflow-engine/internal/types"
+)
+
+// subscriberBuffer 每个订阅者的事件缓冲区大小
+// 订阅者消费过慢导致缓冲区写满时会被断开，可携带 Last-Event-ID 重新订阅
+const subscriberBuffer = 256
+
+// EventHandler 运行事件回调
+type EventHandler func(event types.RunEvent)
+
+// Run 一次运行
+type Run struct {
+	ID string
+
+	spec   Spec
+	ctx    context.Context
+	cancel context.CancelFunc
+	record types.WorkflowExecutionResult // 初始运行记录
+	direct EventHandler                  // 同步执行时直接接收事件的回调
+
+	finished chan struct{} // 运行结束时关闭
+
+	mu     sync.Mutex
+	events []types.RunEvent // 已发生的事件，供重新订阅时回放
+	subs   map[chan types.RunEvent]struct{}
+	done   bool
+}
+
+// Done 返回运行结束时关闭的通道
+func (r *Run) Done() <-chan struct{} {
+	return r.finished
+}
+
+// Subscribe 订阅运行事件
+// 返回 ID 大于 afterID 的已发生事件和后续事件的通道；运行结束后通道被关闭
+func (r *Run) Subscribe(afterID int64) ([]types.RunEvent, <-chan types.RunEvent, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var history []types.RunEvent
+	for _, event := range r.events {
+		if event.ID > afterID {
+			history = append(history, event)
+		}
+	}
+
+	ch := make(chan types.RunEvent, subscriberBuffer)
+	if r.done {
+		close(ch)
+		return history, ch, func() {}
+	}
+
+	r.subs[ch] = struct{}{}
+	unsubscribe := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.subs[ch]; ok {
+			delete(r.subs, ch)
+			close(ch)
+		}
+	}
+	return history, ch, unsubscribe
+}
+
+// publish 为事件分配序号、记录并广播给所有订阅者
+func (r *Run) publish(event string, data interface{}) {
+	r.mu.Lock()
+	e := types.RunEvent{ID: int64(len(r.events)) + 1, Event: event, Data: data}
+	r.events = append(r.events, e)
+	for ch := range r.subs {
+		select {
+		case ch <- e:
+		default:
+			delete(r.subs, ch)
+			close(ch)
+		}
+	}
+	r.mu.Unlock()
+
+	if r.direct != nil {
+		r.direct(e)
+	}
+}
+
+// complete 推送 complete 事件并关闭所有订阅，返回完整的事件流
+func (r *Run) complete(result types.WorkflowExecutionResult) []types.RunEvent {
+	r.publish("complete", result)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = true
+	for ch := range r.subs {
+		delete(r.subs, ch)
+		close(ch)
+	}
+	close(r.finished)
+	return r.events
+}
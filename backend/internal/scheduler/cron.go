@@ -0,0 +1,263 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron 解析后的 cron 表达式
+// 每个字段用位图表示允许的取值
+type Cron struct {
+	second uint64
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// 日期和星期同时受限时按标准 cron 语义取并集
+	domRestricted bool
+	dowRestricted bool
+}
+
+// fieldBounds 字段取值范围
+type fieldBounds struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	secondBounds = fieldBounds{name: "秒", min: 0, max: 59}
+	minuteBounds = fieldBounds{name: "分", min: 0, max: 59}
+	hourBounds   = fieldBounds{name: "时", min: 0, max: 23}
+	domBounds    = fieldBounds{name: "日", min: 1, max: 31}
+	monthBounds  = fieldBounds{name: "月", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	dowBounds = fieldBounds{name: "星期", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+// macros 预定义表达式
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron 解析标准 cron 表达式
+// 支持 5 字段（分 时 日 月 星期）和 6 字段（秒 分 时 日 月 星期），
+// 以及 *、?、列表（,）、范围（-）、步长（/）、月份和星期英文缩写、@daily 等预定义表达式
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron 表达式需为 5 或 6 个字段: %q", expr)
+	}
+
+	c := &Cron{}
+	var err error
+	if c.second, err = parseField(fields[0], secondBounds); err != nil {
+		return nil, err
+	}
+	if c.minute, err = parseField(fields[1], minuteBounds); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[2], hourBounds); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[3], domBounds); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[4], monthBounds); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[5], dowBounds); err != nil {
+		return nil, err
+	}
+
+	// 星期 7 等同于 0（周日）
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domRestricted = !isWildcard(fields[3])
+	c.dowRestricted = !isWildcard(fields[5])
+	return c, nil
+}
+
+// Next 返回严格晚于 t 的下一个触发时间（按 t 所在时区计算），找不到时返回零值
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+
+	// 最多向后搜索 5 年，避免 2 月 30 日之类永远不会触发的表达式死循环
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = localTime(t.Year(), t.Month()+1, 1, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = localTime(t.Year(), t.Month(), t.Day()+1, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = localTime(t.Year(), t.Month(), t.Day(), t.Hour()+1, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if c.second&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+		// 夏令时结束时重复出现的本地时间只在第一次出现时触发，小时不受限的表达式仍按实际时间每小时触发
+		if c.hour != allHours {
+			if end, ok := repeatedUntil(t); ok {
+				t = end
+				continue
+			}
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// allHours 小时字段不受限时的位图
+const allHours = 1<<24 - 1
+
+// localTime 返回 loc 中指定的整点本地时间
+// 该时间因夏令时开始被跳过时返回跳变后的第一个时刻，避免 time.Date 向前归一化导致搜索回退
+func localTime(year int, month time.Month, day, hour int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, loc)
+	want := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if !got.Equal(want) {
+		_, end := t.ZoneBounds()
+		return end
+	}
+	return t
+}
+
+// repeatedUntil 判断 t 的本地时间是否在夏令时结束后第二次出现，是则返回重复区间的结束时刻
+func repeatedUntil(t time.Time) (time.Time, bool) {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return time.Time{}, false
+	}
+	_, offset := t.Zone()
+	_, prevOffset := start.Add(-time.Second).Zone()
+	end := start.Add(time.Duration(prevOffset-offset) * time.Second)
+	if t.Before(end) {
+		return end, true
+	}
+	return time.Time{}, false
+}
+
+// dayMatches 判断日期是否匹配日和星期字段
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// parseField 解析单个字段为位图
+func parseField(field string, bounds fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		b, err := parsePart(part, bounds)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+// parsePart 解析字段中的一项：*、n、a-b，均可带 /step
+func parsePart(part string, bounds fieldBounds) (uint64, error) {
+	rangePart, step := part, 1
+	if i := strings.Index(part, "/"); i >= 0 {
+		s, err := strconv.Atoi(part[i+1:])
+		if err != nil || s <= 0 {
+			return 0, fmt.Errorf("%s字段步长无效: %q", bounds.name, part)
+		}
+		rangePart, step = part[:i], s
+	}
+
+	var low, high int
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		low, high = bounds.min, bounds.max
+	case strings.Contains(rangePart, "-"):
+		ends := strings.SplitN(rangePart, "-", 2)
+		var err error
+		if low, err = parseValue(ends[0], bounds); err != nil {
+			return 0, err
+		}
+		if high, err = parseValue(ends[1], bounds); err != nil {
+			return 0, err
+		}
+	default:
+		v, err := parseValue(rangePart, bounds)
+		if err != nil {
+			return 0, err
+		}
+		low, high = v, v
+		// n/step 表示从 n 开始到最大值
+		if step > 1 {
+			high = bounds.max
+		}
+	}
+
+	if low > high {
+		return 0, fmt.Errorf("%s字段范围无效: %q", bounds.name, part)
+	}
+
+	var bits uint64
+	for v := low; v <= high; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+// parseValue 解析字段中的单个取值（数字或英文缩写）
+func parseValue(s string, bounds fieldBounds) (int, error) {
+	if v, ok := bounds.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < bounds.min || v > bounds.max {
+		return 0, fmt.Errorf("%s字段取值无效（%d-%d）: %q", bounds.name, bounds.min, bounds.max, s)
+	}
+	return v, nil
+}
+
+// isWildcard 判断字段是否不限制取值
+func isWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
@@ -0,0 +1,224 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", `cron 表达式需为 5 或 6 个字段: ""`},
+		{"* * * *", `cron 表达式需为 5 或 6 个字段: "* * * *"`},
+		{"* * * * * * *", `cron 表达式需为 5 或 6 个字段: "* * * * * * *"`},
+		{"@often", `cron 表达式需为 5 或 6 个字段: "@often"`},
+		{"60 * * * * *", `秒字段取值无效（0-59）: "60"`},
+		{"60 * * * *", `分字段取值无效（0-59）: "60"`},
+		{"* 24 * * *", `时字段取值无效（0-23）: "24"`},
+		{"* * 0 * *", `日字段取值无效（1-31）: "0"`},
+		{"* * * 13 *", `月字段取值无效（1-12）: "13"`},
+		{"* * * * 8", `星期字段取值无效（0-7）: "8"`},
+		{"* * * FOO *", `月字段取值无效（1-12）: "FOO"`},
+		{"* * * * MON-FOO", `星期字段取值无效（0-7）: "FOO"`},
+		{"a * * * *", `分字段取值无效（0-59）: "a"`},
+		{"1,,2 * * * *", `分字段取值无效（0-59）: ""`},
+		{"*/0 * * * *", `分字段步长无效: "*/0"`},
+		{"*/x * * * *", `分字段步长无效: "*/x"`},
+		{"5-1 * * * *", `分字段范围无效: "5-1"`},
+		{"1-2-3 * * * *", `分字段取值无效（0-59）: "2-3"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ParseCron(%q) error = %v, want %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		tz   string
+		from string
+		want []string // 依次调用 Next 得到的触发时间，nil 表示没有后续触发时间
+	}{
+		{
+			name: "5 字段步长",
+			expr: "*/15 * * * *",
+			from: "2024-01-01T00:07:00Z",
+			want: []string{"2024-01-01T00:15:00Z", "2024-01-01T00:30:00Z", "2024-01-01T00:45:00Z", "2024-01-01T01:00:00Z"},
+		},
+		{
+			name: "6 字段含秒",
+			expr: "*/20 * * * * *",
+			from: "2024-01-01T00:00:05Z",
+			want: []string{"2024-01-01T00:00:20Z", "2024-01-01T00:00:40Z", "2024-01-01T00:01:00Z"},
+		},
+		{
+			name: "严格晚于起始时间",
+			expr: "0 0 * * *",
+			from: "2024-01-01T00:00:00Z",
+			want: []string{"2024-01-02T00:00:00Z"},
+		},
+		{
+			name: "起始时间的亚秒部分被截断",
+			expr: "* * * * * *",
+			from: "2024-01-01T00:00:00.5Z",
+			want: []string{"2024-01-01T00:00:01Z", "2024-01-01T00:00:02Z"},
+		},
+		{
+			name: "范围",
+			expr: "0 9-11 * * *",
+			from: "2024-01-01T10:30:00Z",
+			want: []string{"2024-01-01T11:00:00Z", "2024-01-02T09:00:00Z", "2024-01-02T10:00:00Z"},
+		},
+		{
+			name: "带步长的范围",
+			expr: "0 0-12/6 * * *",
+			from: "2024-01-01T00:00:00Z",
+			want: []string{"2024-01-01T06:00:00Z", "2024-01-01T12:00:00Z", "2024-01-02T00:00:00Z"},
+		},
+		{
+			name: "起始值加步长",
+			expr: "5/20 * * * *",
+			from: "2024-01-01T00:00:00Z",
+			want: []string{"2024-01-01T00:05:00Z", "2024-01-01T00:25:00Z", "2024-01-01T00:45:00Z", "2024-01-01T01:05:00Z"},
+		},
+		{
+			name: "列表",
+			expr: "0 0 1,15 * *",
+			from: "2024-01-02T00:00:00Z",
+			want: []string{"2024-01-15T00:00:00Z", "2024-02-01T00:00:00Z", "2024-02-15T00:00:00Z"},
+		},
+		{
+			name: "月份和星期缩写",
+			expr: "0 0 * jan,MAR Mon-WED",
+			from: "2024-01-31T00:00:00Z",
+			want: []string{"2024-03-04T00:00:00Z", "2024-03-05T00:00:00Z", "2024-03-06T00:00:00Z", "2024-03-11T00:00:00Z"},
+		},
+		{
+			name: "星期 7 表示周日",
+			expr: "0 0 * * 7",
+			from: "2024-01-01T00:00:00Z",
+			want: []string{"2024-01-07T00:00:00Z", "2024-01-14T00:00:00Z"},
+		},
+		{
+			name: "日期不限时只按星期匹配",
+			expr: "0 0 ? * FRI",
+			from: "2024-09-01T00:00:00Z",
+			want: []string{"2024-09-06T00:00:00Z", "2024-09-13T00:00:00Z", "2024-09-20T00:00:00Z"},
+		},
+		{
+			name: "日期和星期同时受限时取并集",
+			expr: "0 0 13 * FRI",
+			from: "2024-09-01T00:00:00Z",
+			want: []string{
+				"2024-09-06T00:00:00Z", "2024-09-13T00:00:00Z", "2024-09-20T00:00:00Z", "2024-09-27T00:00:00Z",
+				"2024-10-04T00:00:00Z", "2024-10-11T00:00:00Z", "2024-10-13T00:00:00Z", "2024-10-18T00:00:00Z",
+			},
+		},
+		{
+			name: "预定义表达式",
+			expr: "@Daily",
+			from: "2024-01-01T12:00:00Z",
+			want: []string{"2024-01-02T00:00:00Z", "2024-01-03T00:00:00Z"},
+		},
+		{
+			name: "闰日",
+			expr: "0 0 29 2 *",
+			from: "2024-03-01T00:00:00Z",
+			want: []string{"2028-02-29T00:00:00Z"},
+		},
+		{
+			name: "永远不会触发",
+			expr: "0 0 30 2 *",
+			from: "2024-01-01T00:00:00Z",
+			want: nil,
+		},
+		{
+			name: "按时区计算",
+			expr: "0 9 * * *",
+			tz:   "Asia/Shanghai",
+			from: "2024-01-01T02:00:00Z",
+			want: []string{"2024-01-02T09:00:00+08:00", "2024-01-03T09:00:00+08:00"},
+		},
+		{
+			name: "按时区计算星期",
+			expr: "0 0 * * MON",
+			tz:   "Asia/Tokyo",
+			from: "2024-01-07T14:00:00Z",
+			want: []string{"2024-01-08T00:00:00+09:00", "2024-01-15T00:00:00+09:00"},
+		},
+		{
+			name: "夏令时开始时被跳过的时间不触发",
+			expr: "30 2 * * *",
+			tz:   "America/New_York",
+			from: "2024-03-10T00:00:00-05:00",
+			want: []string{"2024-03-11T02:30:00-04:00", "2024-03-12T02:30:00-04:00"},
+		},
+		{
+			name: "夏令时开始当天按实际时间每小时触发",
+			expr: "0 * * * *",
+			tz:   "America/New_York",
+			from: "2024-03-10T00:30:00-05:00",
+			want: []string{"2024-03-10T01:00:00-05:00", "2024-03-10T03:00:00-04:00", "2024-03-10T04:00:00-04:00"},
+		},
+		{
+			name: "夏令时结束时重复的时间只触发一次",
+			expr: "30 1 * * *",
+			tz:   "America/New_York",
+			from: "2024-11-03T00:00:00-04:00",
+			want: []string{"2024-11-03T01:30:00-04:00", "2024-11-04T01:30:00-05:00"},
+		},
+		{
+			name: "夏令时结束当天按实际时间每小时触发",
+			expr: "0 * * * *",
+			tz:   "America/New_York",
+			from: "2024-11-03T00:30:00-04:00",
+			want: []string{"2024-11-03T01:00:00-04:00", "2024-11-03T01:00:00-05:00", "2024-11-03T02:00:00-05:00"},
+		},
+		{
+			name: "夏令时开始时被跳过的午夜不触发",
+			expr: "0 0 * * *",
+			tz:   "America/Santiago",
+			from: "2024-09-07T12:00:00-04:00",
+			want: []string{"2024-09-09T00:00:00-03:00", "2024-09-10T00:00:00-03:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			loc, err := loadLocation(tt.tz)
+			if err != nil {
+				t.Fatalf("loadLocation(%q): %v", tt.tz, err)
+			}
+			next, err := time.Parse(time.RFC3339, tt.from)
+			if err != nil {
+				t.Fatalf("time.Parse(%q): %v", tt.from, err)
+			}
+			next = next.In(loc)
+
+			if tt.want == nil {
+				if next = c.Next(next); !next.IsZero() {
+					t.Errorf("Next = %s, want zero time", next.Format(time.RFC3339))
+				}
+				return
+			}
+			for i, want := range tt.want {
+				next = c.Next(next)
+				if got := next.Format(time.RFC3339); got != want {
+					t.Fatalf("Next #%d = %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"workflow-engine/internal/runs"
+	"workflow-engine/internal/store"
+	"workflow-engine/internal/types"
+)
+
+// 重叠策略：上一次触发的运行尚未结束时如何处理新的触发
+const (
+	OverlapSkip  = "skip"  // 跳过本次触发
+	OverlapQueue = "queue" // 等待上一次运行结束后再执行，最多排队一次
+	OverlapAllow = "allow" // 允许并发执行
+)
+
+// Scheduler 进程内定时调度器
+// 每个启用的定时触发器对应一个协程，到期后通过运行管理器提交普通运行
+type Scheduler struct {
+	store   store.Store
+	manager *runs.Manager
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// entry 调度中的定时触发器
+type entry struct {
+	schedule types.Schedule
+	cron     *Cron
+	loc      *time.Location
+	stop     chan struct{}
+	lastDone <-chan struct{} // 最近一次提交（或排队）的运行结束时关闭
+	queued   bool            // 是否已有一次触发在排队等待上一次运行结束
+}
+
+// New 创建调度器
+func New(st store.Store, manager *runs.Manager) *Scheduler {
+	return &Scheduler{
+		store:   st,
+		manager: manager,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Start 加载并启动所有已启用的定时触发器
+func (s *Scheduler) Start() error {
+	schedules, err := s.store.ListSchedules()
+	if err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		if err := s.Put(schedule); err != nil {
+			log.Printf("启动定时触发器失败 (%s): %v", schedule.ID, err)
+		}
+	}
+	return nil
+}
+
+// Validate 校验定时触发器配置
+func Validate(schedule types.Schedule) error {
+	if _, err := ParseCron(schedule.Cron); err != nil {
+		return err
+	}
+	if _, err := loadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("时区无效: %s", schedule.Timezone)
+	}
+	switch schedule.Overlap {
+	case "", OverlapSkip, OverlapQueue, OverlapAllow:
+	default:
+		return fmt.Errorf("重叠策略无效: %s（可选 skip、queue、allow）", schedule.Overlap)
+	}
+	return nil
+}
+
+// Put 启动或重新加载定时触发器，未启用的触发器只会停止调度
+func (s *Scheduler) Put(schedule types.Schedule) error {
+	if err := Validate(schedule); err != nil {
+		return err
+	}
+	cron, _ := ParseCron(schedule.Cron)
+	loc, _ := loadLocation(schedule.Timezone)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lastDone <-chan struct{}
+	if old, ok := s.entries[schedule.ID]; ok {
+		close(old.stop)
+		lastDone = old.lastDone
+		delete(s.entries, schedule.ID)
+	}
+	if !schedule.Enabled {
+		return nil
+	}
+
+	e := &entry{
+		schedule: schedule,
+		cron:     cron,
+		loc:      loc,
+		stop:     make(chan struct{}),
+		lastDone: lastDone,
+	}
+	s.entries[schedule.ID] = e
+	go s.loop(e)
+	return nil
+}
+
+// Remove 停止定时触发器
+func (s *Scheduler) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[id]; ok {
+		close(e.stop)
+		delete(s.entries, id)
+	}
+}
+
+// NextFireTime 计算定时触发器的下一次触发时间，未启用或无法触发时返回空字符串
+func NextFireTime(schedule types.Schedule, now time.Time) string {
+	if !schedule.Enabled {
+		return ""
+	}
+	cron, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return ""
+	}
+	loc, err := loadLocation(schedule.Timezone)
+	if err != nil {
+		return ""
+	}
+	next := cron.Next(now.In(loc))
+	if next.IsZero() {
+		return ""
+	}
+	return next.Format(time.RFC3339)
+}
+
+// loop 定时触发器的调度循环
+func (s *Scheduler) loop(e *entry) {
+	for {
+		next := e.cron.Next(time.Now().In(e.loc))
+		if next.IsZero() {
+			log.Printf("定时触发器 %s 没有后续触发时间，停止调度", e.schedule.ID)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-e.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.fire(e, next)
+		}
+	}
+}
+
+// fire 按重叠策略提交一次运行
+func (s *Scheduler) fire(e *entry, fireTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 已被停止或替换的触发器不再提交
+	if s.entries[e.schedule.ID] != e {
+		return
+	}
+
+	prev := e.lastDone
+	running := prev != nil && !isClosed(prev)
+
+	switch e.schedule.Overlap {
+	case OverlapAllow:
+	case OverlapQueue:
+		if running {
+			// 最多保留一次排队的触发，避免运行耗时长于触发间隔时排队无限增长
+			if e.queued {
+				log.Printf("定时触发器 %s 已有一次触发在排队，丢弃本次触发", e.schedule.ID)
+				return
+			}
+			e.queued = true
+			done := make(chan struct{})
+			e.lastDone = done
+			go func() {
+				<-prev
+				s.mu.Lock()
+				e.queued = false
+				active := s.entries[e.schedule.ID] == e
+				s.mu.Unlock()
+				if !active {
+					close(done)
+					return
+				}
+				s.submit(e, fireTime, done)
+			}()
+			return
+		}
+	default:
+		if running {
+			log.Printf("定时触发器 %s 的上一次运行尚未结束，跳过本次触发", e.schedule.ID)
+			return
+		}
+	}
+
+	done := make(chan struct{})
+	e.lastDone = done
+	go s.submit(e, fireTime, done)
+}
+
+// submit 提交运行并记录到定时触发器，运行结束后关闭 done
+func (s *Scheduler) submit(e *entry, fireTime time.Time, done chan struct{}) {
+	defer close(done)
+
+	spec, err := s.manager.StoredSpec(e.schedule.WorkflowID, e.schedule.Version)
+	if err != nil {
+		log.Printf("定时触发器 %s 加载工作流失败: %v", e.schedule.ID, err)
+		return
+	}
+	spec.Inputs = e.schedule.Inputs
+	run, err := s.manager.Submit(spec)
+	if err != nil {
+		log.Printf("定时触发器 %s 提交运行失败: %v", e.schedule.ID, err)
+		return
+	}
+
+	s.recordFire(e.schedule.ID, run.ID, fireTime)
+	<-run.Done()
+}
+
+// recordFire 记录定时触发器最近一次触发，触发器已被删除时忽略
+func (s *Scheduler) recordFire(id, runID string, fireTime time.Time) {
+	err := s.store.RecordScheduleFire(id, runID, fireTime.Format(time.RFC3339))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.Printf("保存定时触发器失败 (%s): %v", id, err)
+	}
+}
+
+// loadLocation 加载时区，空字符串表示 UTC
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// isClosed 判断通道是否已关闭
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
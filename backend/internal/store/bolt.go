@@ -0,0 +1,525 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"workflow-engine/internal/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	workflowsBucket = []byte("workflows")
+	versionsBucket  = []byte("workflow_versions")
+	runsBucket      = []byte("runs")
+	eventsBucket    = []byte("run_events")
+	schedulesBucket = []byte("schedules")
+	webhooksBucket  = []byte("webhooks")
+)
+
+// BoltStore 基于 BoltDB 单文件的嵌入式存储
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt 打开（不存在时创建）BoltDB 存储文件
+func OpenBolt(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{workflowsBucket, versionsBucket, runsBucket, eventsBucket, schedulesBucket, webhooksBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// ListWorkflows 列出所有工作流（按更新时间倒序）
+func (s *BoltStore) ListWorkflows() ([]types.WorkflowDefinition, error) {
+	defs := []types.WorkflowDefinition{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(workflowsBucket).ForEach(func(k, v []byte) error {
+			var def types.WorkflowDefinition
+			if err := json.Unmarshal(v, &def); err != nil {
+				return err
+			}
+			defs = append(defs, def)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].UpdatedAt > defs[j].UpdatedAt
+	})
+	return defs, nil
+}
+
+// GetWorkflow 获取工作流
+func (s *BoltStore) GetWorkflow(id string) (types.WorkflowDefinition, error) {
+	var def types.WorkflowDefinition
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(workflowsBucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &def)
+	})
+	return def, err
+}
+
+// SaveWorkflow 创建或更新工作流，并追加一个新版本
+// 版本号以已存储的当前版本为准，与传入的 def.Version 无关
+func (s *BoltStore) SaveWorkflow(def types.WorkflowDefinition, promotedFrom int) (types.WorkflowDefinition, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		workflows := tx.Bucket(workflowsBucket)
+
+		def.Version = 1
+		if v := workflows.Get([]byte(def.ID)); v != nil {
+			var current types.WorkflowDefinition
+			if err := json.Unmarshal(v, &current); err != nil {
+				return err
+			}
+			def.Version = current.Version + 1
+		}
+
+		version := types.WorkflowVersion{
+			WorkflowID:   def.ID,
+			Version:      def.Version,
+			Name:         def.Name,
+			Description:  def.Description,
+			Workflow:     def.Workflow,
+			PromotedFrom: promotedFrom,
+			CreatedAt:    def.UpdatedAt,
+		}
+		if err := putJSON(tx.Bucket(versionsBucket), versionKey(def.ID, def.Version), version); err != nil {
+			return err
+		}
+		return putJSON(workflows, []byte(def.ID), def)
+	})
+	return def, err
+}
+
+// DeleteWorkflow 删除工作流及其所有版本
+func (s *BoltStore) DeleteWorkflow(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(workflowsBucket)
+		if b.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		if err := checkReferences(tx, id); err != nil {
+			return err
+		}
+
+		// 删除游标遍历中的键会打乱游标位置，先收集再删除
+		var keys [][]byte
+		prefix := versionPrefix(id)
+		c := tx.Bucket(versionsBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for _, k := range keys {
+			if err := tx.Bucket(versionsBucket).Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return b.Delete([]byte(id))
+	})
+}
+
+// checkReferences 检查是否仍有定时、webhook 触发器或其他工作流的子工作流节点引用工作流，有则返回 ErrInUse
+func checkReferences(tx *bolt.Tx, workflowID string) error {
+	var refs []string
+	schedules, err := countReferences(tx.Bucket(schedulesBucket), workflowID)
+	if err != nil {
+		return err
+	}
+	if schedules > 0 {
+		refs = append(refs, fmt.Sprintf("%d 个定时触发器", schedules))
+	}
+	webhooks, err := countReferences(tx.Bucket(webhooksBucket), workflowID)
+	if err != nil {
+		return err
+	}
+	if webhooks > 0 {
+		refs = append(refs, fmt.Sprintf("%d 个 webhook 触发器", webhooks))
+	}
+	callers := 0
+	err = tx.Bucket(workflowsBucket).ForEach(func(k, v []byte) error {
+		if string(k) == workflowID {
+			return nil
+		}
+		var def types.WorkflowDefinition
+		if err := json.Unmarshal(v, &def); err != nil {
+			return err
+		}
+		if callsWorkflow(def.Workflow, workflowID) {
+			callers++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if callers > 0 {
+		refs = append(refs, fmt.Sprintf("%d 个工作流的子工作流节点", callers))
+	}
+
+	if len(refs) > 0 {
+		return fmt.Errorf("%w: %s", ErrInUse, strings.Join(refs, "、"))
+	}
+	return nil
+}
+
+// subWorkflowType 子工作流节点的任务类型，与 executor.SubWorkflowType 相同
+const subWorkflowType = "sub-workflow"
+
+// callsWorkflow 判断工作流（含循环节点的子图）中是否有子工作流节点调用指定工作流
+func callsWorkflow(workflow types.Workflow, workflowID string) bool {
+	for _, node := range workflow.Nodes {
+		if node.Type == subWorkflowType && node.Config["workflowId"] == workflowID {
+			return true
+		}
+		if node.Body != nil && callsWorkflow(*node.Body, workflowID) {
+			return true
+		}
+	}
+	return false
+}
+
+// countReferences 统计桶中 workflowId 为指定工作流的触发器数量
+func countReferences(b *bolt.Bucket, workflowID string) (int, error) {
+	n := 0
+	err := b.ForEach(func(k, v []byte) error {
+		var trigger struct {
+			WorkflowID string `json:"workflowId"`
+		}
+		if err := json.Unmarshal(v, &trigger); err != nil {
+			return err
+		}
+		if trigger.WorkflowID == workflowID {
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// ListVersions 列出工作流的所有版本
+func (s *BoltStore) ListVersions(id string) ([]types.WorkflowVersion, error) {
+	versions := []types.WorkflowVersion{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(workflowsBucket).Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+
+		prefix := versionPrefix(id)
+		c := tx.Bucket(versionsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var version types.WorkflowVersion
+			if err := json.Unmarshal(v, &version); err != nil {
+				return err
+			}
+			versions = append(versions, version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetVersion 获取工作流的指定版本
+func (s *BoltStore) GetVersion(id string, version int) (types.WorkflowVersion, error) {
+	var result types.WorkflowVersion
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(versionsBucket).Get(versionKey(id, version))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &result)
+	})
+	return result, err
+}
+
+// SaveRun 创建或覆盖运行记录
+// 运行 ID 为按时间递增的 UUIDv7，键的字节序即运行的先后顺序
+func (s *BoltStore) SaveRun(run types.WorkflowExecutionResult) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(runsBucket), []byte(run.RunID), run)
+	})
+}
+
+// GetRun 获取运行记录
+func (s *BoltStore) GetRun(runID string) (types.WorkflowExecutionResult, error) {
+	var run types.WorkflowExecutionResult
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(runsBucket).Get([]byte(runID))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &run)
+	})
+	return run, err
+}
+
+// ListRuns 按条件查询运行记录，从最新的记录开始倒序遍历
+func (s *BoltStore) ListRuns(filter RunFilter) ([]types.WorkflowExecutionResult, int, error) {
+	runs := []types.WorkflowExecutionResult{}
+	total := 0
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(runsBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var run types.WorkflowExecutionResult
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+			if !filter.Match(run) {
+				continue
+			}
+			total++
+			if total > filter.Offset && (filter.Limit <= 0 || len(runs) < filter.Limit) {
+				runs = append(runs, run)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+// SaveRunEvents 保存运行的完整事件流
+func (s *BoltStore) SaveRunEvents(runID string, events []types.RunEvent) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(eventsBucket), []byte(runID), events)
+	})
+}
+
+// ListRunEvents 获取运行中 ID 大于 afterID 的事件
+func (s *BoltStore) ListRunEvents(runID string, afterID int64) ([]types.RunEvent, error) {
+	var events []types.RunEvent
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(eventsBucket).Get([]byte(runID))
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(v, &events)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.RunEvent{}
+	for _, event := range events {
+		if event.ID > afterID {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+// ListSchedules 列出所有定时触发器（按创建时间排序）
+func (s *BoltStore) ListSchedules() ([]types.Schedule, error) {
+	schedules := []types.Schedule{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(schedulesBucket).ForEach(func(k, v []byte) error {
+			var schedule types.Schedule
+			if err := json.Unmarshal(v, &schedule); err != nil {
+				return err
+			}
+			schedules = append(schedules, schedule)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt < schedules[j].CreatedAt
+	})
+	return schedules, nil
+}
+
+// GetSchedule 获取定时触发器
+func (s *BoltStore) GetSchedule(id string) (types.Schedule, error) {
+	var schedule types.Schedule
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(schedulesBucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &schedule)
+	})
+	return schedule, err
+}
+
+// SaveSchedule 创建或覆盖定时触发器
+func (s *BoltStore) SaveSchedule(schedule types.Schedule) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(schedulesBucket), []byte(schedule.ID), schedule)
+	})
+}
+
+// RecordScheduleFire 在同一事务内读取并更新定时触发器最近一次触发，避免覆盖并发保存的配置
+func (s *BoltStore) RecordScheduleFire(id, runID, fireTime string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(schedulesBucket)
+		v := b.Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		var schedule types.Schedule
+		if err := json.Unmarshal(v, &schedule); err != nil {
+			return err
+		}
+		schedule.LastRunID = runID
+		schedule.LastFireTime = fireTime
+		return putJSON(b, []byte(id), schedule)
+	})
+}
+
+// DeleteSchedule 删除定时触发器
+func (s *BoltStore) DeleteSchedule(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(schedulesBucket)
+		if b.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// ListWebhooks 列出所有 webhook 触发器（按创建时间排序）
+func (s *BoltStore) ListWebhooks() ([]types.Webhook, error) {
+	webhooks := []types.Webhook{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(webhooksBucket).ForEach(func(k, v []byte) error {
+			webhook, err := decodeWebhook(v)
+			if err != nil {
+				return err
+			}
+			webhooks = append(webhooks, webhook)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt < webhooks[j].CreatedAt
+	})
+	return webhooks, nil
+}
+
+// GetWebhook 获取 webhook 触发器
+func (s *BoltStore) GetWebhook(id string) (types.Webhook, error) {
+	var webhook types.Webhook
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(webhooksBucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		var err error
+		webhook, err = decodeWebhook(v)
+		return err
+	})
+	return webhook, err
+}
+
+// GetWebhookByToken 按令牌获取 webhook 触发器
+// webhook 数量通常很少，直接遍历查找
+func (s *BoltStore) GetWebhookByToken(token string) (types.Webhook, error) {
+	webhooks, err := s.ListWebhooks()
+	if err != nil {
+		return types.Webhook{}, err
+	}
+	for _, webhook := range webhooks {
+		if webhook.Token == token {
+			return webhook, nil
+		}
+	}
+	return types.Webhook{}, ErrNotFound
+}
+
+// SaveWebhook 创建或覆盖 webhook 触发器
+func (s *BoltStore) SaveWebhook(webhook types.Webhook) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(webhooksBucket), []byte(webhook.ID), webhookRecord{webhook, webhook.Secret})
+	})
+}
+
+// webhookRecord webhook 触发器的存储格式，types.Webhook 序列化时不包含签名密钥
+type webhookRecord struct {
+	types.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// decodeWebhook 解析存储的 webhook 触发器
+func decodeWebhook(v []byte) (types.Webhook, error) {
+	var record webhookRecord
+	if err := json.Unmarshal(v, &record); err != nil {
+		return types.Webhook{}, err
+	}
+	webhook := record.Webhook
+	webhook.Secret = record.Secret
+	webhook.HasSecret = webhook.Secret != ""
+	return webhook, nil
+}
+
+// DeleteWebhook 删除 webhook 触发器
+func (s *BoltStore) DeleteWebhook(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(webhooksBucket)
+		if b.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// Close 关闭存储
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// putJSON 以 JSON 格式写入键值
+func putJSON(b *bolt.Bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+// versionPrefix 工作流版本键的前缀
+func versionPrefix(id string) []byte {
+	return []byte(id + "/")
+}
+
+// versionKey 工作流版本键，版本号补零以保证按字节序即按版本号排序
+func versionKey(id string, version int) []byte {
+	return []byte(fmt.Sprintf("%s/%010d", id, version))
+}
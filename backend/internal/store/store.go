@@ -0,0 +1,95 @@
+package store
+
+import (
+	"errors"
+	"time"
+	"workflow-engine/internal/types"
+)
+
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("记录不存在")
+
+// ErrInUse 记录仍被其他记录引用，不能删除
+var ErrInUse = errors.New("记录仍被引用")
+
+// Store 工作流存储接口
+type Store interface {
+	// ListWorkflows 列出所有工作流
+	ListWorkflows() ([]types.WorkflowDefinition, error)
+	// GetWorkflow 获取工作流，不存在时返回 ErrNotFound
+	GetWorkflow(id string) (types.WorkflowDefinition, error)
+	// SaveWorkflow 创建或更新工作流，每次保存都会生成一个新的不可变版本
+	// promotedFrom 不为 0 时表示本次保存是对该历史版本的回滚
+	SaveWorkflow(def types.WorkflowDefinition, promotedFrom int) (types.WorkflowDefinition, error)
+	// DeleteWorkflow 删除工作流及其所有版本，不存在时返回 ErrNotFound，仍有定时、webhook 触发器或其他工作流的子工作流节点引用该工作流时返回 ErrInUse
+	DeleteWorkflow(id string) error
+	// ListVersions 列出工作流的所有版本（按版本号升序）
+	ListVersions(id string) ([]types.WorkflowVersion, error)
+	// GetVersion 获取工作流的指定版本，不存在时返回 ErrNotFound
+	GetVersion(id string, version int) (types.WorkflowVersion, error)
+	// SaveRun 创建或覆盖运行记录
+	SaveRun(run types.WorkflowExecutionResult) error
+	// GetRun 获取运行记录，不存在时返回 ErrNotFound
+	GetRun(runID string) (types.WorkflowExecutionResult, error)
+	// ListRuns 按条件查询运行记录（按开始时间倒序），同时返回符合条件的总数
+	ListRuns(filter RunFilter) ([]types.WorkflowExecutionResult, int, error)
+	// SaveRunEvents 保存运行的完整事件流
+	SaveRunEvents(runID string, events []types.RunEvent) error
+	// ListRunEvents 获取运行中 ID 大于 afterID 的事件，未保存过事件时返回空列表
+	ListRunEvents(runID string, afterID int64) ([]types.RunEvent, error)
+	// ListSchedules 列出所有定时触发器
+	ListSchedules() ([]types.Schedule, error)
+	// GetSchedule 获取定时触发器，不存在时返回 ErrNotFound
+	GetSchedule(id string) (types.Schedule, error)
+	// SaveSchedule 创建或覆盖定时触发器
+	SaveSchedule(schedule types.Schedule) error
+	// RecordScheduleFire 更新定时触发器最近一次触发的运行 ID 和时间，不修改其他字段，不存在时返回 ErrNotFound
+	RecordScheduleFire(id, runID, fireTime string) error
+	// DeleteSchedule 删除定时触发器，不存在时返回 ErrNotFound
+	DeleteSchedule(id string) error
+	// ListWebhooks 列出所有 webhook 触发器
+	ListWebhooks() ([]types.Webhook, error)
+	// GetWebhook 获取 webhook 触发器，不存在时返回 ErrNotFound
+	GetWebhook(id string) (types.Webhook, error)
+	// GetWebhookByToken 按令牌获取 webhook 触发器，不存在时返回 ErrNotFound
+	GetWebhookByToken(token string) (types.Webhook, error)
+	// SaveWebhook 创建或覆盖 webhook 触发器
+	SaveWebhook(webhook types.Webhook) error
+	// DeleteWebhook 删除 webhook 触发器，不存在时返回 ErrNotFound
+	DeleteWebhook(id string) error
+	// Close 关闭存储
+	Close() error
+}
+
+// RunFilter 运行记录查询条件，零值字段表示不限制
+type RunFilter struct {
+	WorkflowID string
+	Status     string
+	From       time.Time // 开始时间下限（含）
+	To         time.Time // 开始时间上限（含）
+	Offset     int
+	Limit      int
+}
+
+// Match 判断运行记录是否符合查询条件（不含分页）
+func (f RunFilter) Match(run types.WorkflowExecutionResult) bool {
+	if f.WorkflowID != "" && run.WorkflowID != f.WorkflowID {
+		return false
+	}
+	if f.Status != "" && run.Status != f.Status {
+		return false
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		startTime, err := time.Parse(time.RFC3339, run.StartTime)
+		if err != nil {
+			return false
+		}
+		if !f.From.IsZero() && startTime.Before(f.From) {
+			return false
+		}
+		if !f.To.IsZero() && startTime.After(f.To) {
+			return false
+		}
+	}
+	return true
+}
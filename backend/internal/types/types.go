@@ -7,9 +7,10 @@ type TaskInput map[string]interface{}
 
 // TaskOutput 任务输出
 type TaskOutput struct {
-	Error string                 `json:"error"`
-	Data  interface{}            `json:"data,omitempty"`
-	Extra map[string]interface{} `json:"-"`
+	Error    string                 `json:"error"`
+	Data     interface{}            `json:"data,omitempty"`
+	Branches []string               `json:"-"` // 选中的输出分支，为空表示不区分分支
+	Extra    map[string]interface{} `json:"-"`
 }
 
 // MarshalJSON 自定义 JSON 序列化
@@ -19,6 +20,9 @@ func (o TaskOutput) MarshalJSON() ([]byte, error) {
 	if o.Data != nil {
 		result["data"] = o.Data
 	}
+	if len(o.Branches) > 0 {
+		result["branches"] = o.Branches
+	}
 	for k, v := range o.Extra {
 		result[k] = v
 	}
@@ -49,53 +53,315 @@ func NewErrorOutput(err string) TaskOutput {
 // TaskExecutor 任务执行器函数类型
 type TaskExecutor func(input TaskInput) TaskOutput
 
+// ParamConfig 参数配置，用于任务参数和工作流输入的声明
+type ParamConfig struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	Label       string        `json:"label"`
+	Required    bool          `json:"required"`
+	Default     interface{}   `json:"default,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Options     []ParamOption `json:"options,omitempty"`
+}
+
+// ParamOption 参数选项
+type ParamOption struct {
+	Label string      `json:"label"`
+	Value interface{} `json:"value"`
+}
+
 // WorkflowNode 工作流节点
 type WorkflowNode struct {
-	ID       string    `json:"id"`
-	Type     string    `json:"type"`
-	Label    string    `json:"label"`
-	Config   TaskInput `json:"config"`
-	Position struct {
+	ID           string            `json:"id"`
+	Type         string            `json:"type"`
+	Label        string            `json:"label"`
+	Config       TaskInput         `json:"config"`
+	Retry        *RetryPolicy      `json:"retry,omitempty"`
+	Timeout      float64           `json:"timeout,omitempty"`      // 单次执行超时时间（秒），0 表示不限制
+	InputMapping map[string]string `json:"inputMapping,omitempty"` // 参数名 -> 上游字段表达式，声明后不再隐式展开前置节点的输出
+	Priority     int               `json:"priority,omitempty"`     // 同时就绪的节点中数值大的先执行
+	OnError      string            `json:"onError,omitempty"`      // 失败后的处理策略: fail（默认）、continue、route
+	Compensation *Compensation     `json:"compensation,omitempty"` // 运行失败时撤销本节点副作用的补偿操作
+	Body         *Workflow         `json:"body,omitempty"`         // 循环节点（for-each）对每一项执行的子图
+	Position     struct {
 		X float64 `json:"x"`
 		Y float64 `json:"y"`
 	} `json:"position"`
 }
 
+// 节点失败后的处理策略
+const (
+	OnErrorFail     = "fail"     // 结束整个运行
+	OnErrorContinue = "continue" // 标记为失败，跳过其下游节点（即使下游节点还有其他成功的前置节点），其他分支继续执行
+	OnErrorRoute    = "route"    // 同 continue，并将失败信息沿错误分支（sourceHandle 为 error）交给处理节点
+)
+
+// Compensation 节点的补偿操作
+// 运行因节点失败而结束时，已成功节点的补偿操作按完成顺序的逆序执行
+// 配置中的表达式可通过 original 访问原节点的输出（original.data、original.input）
+type Compensation struct {
+	Type    string    `json:"type"`
+	Config  TaskInput `json:"config"`
+	Timeout float64   `json:"timeout,omitempty"` // 超时时间（秒），0 表示不限制
+}
+
+// RetryPolicy 节点重试策略
+type RetryPolicy struct {
+	MaxAttempts   int      `json:"maxAttempts"`             // 最大尝试次数（含首次执行）
+	InitialDelay  float64  `json:"initialDelay,omitempty"`  // 首次重试前的等待时间（秒）
+	Multiplier    float64  `json:"multiplier,omitempty"`    // 每次重试等待时间的倍数，默认 2
+	MaxDelay      float64  `json:"maxDelay,omitempty"`      // 等待时间上限（秒），0 表示不限制
+	Jitter        float64  `json:"jitter,omitempty"`        // 随机抖动比例（0~1）
+	RetryOn       []string `json:"retryOn,omitempty"`       // 可重试的错误信息关键字
+	RetryOnStatus []int    `json:"retryOnStatus,omitempty"` // 可重试的 HTTP 状态码
+}
+
 // WorkflowEdge 工作流边
 type WorkflowEdge struct {
-	Source string `json:"source"`
-	Target string `json:"target"`
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	SourceHandle string `json:"sourceHandle,omitempty"` // 源节点的输出分支，如 if-condition 的 true/false
 }
 
 // Workflow 工作流定义
 type Workflow struct {
-	Nodes []WorkflowNode `json:"nodes"`
-	Edges []WorkflowEdge `json:"edges"`
+	Nodes       []WorkflowNode   `json:"nodes"`
+	Edges       []WorkflowEdge   `json:"edges"`
+	MaxParallel int              `json:"maxParallel,omitempty"` // 单次运行最大并行节点数，0 表示仅受全局上限约束
+	Timeout     float64          `json:"timeout,omitempty"`     // 整个运行的超时时间（秒），0 表示不限制
+	Strict      bool             `json:"strict,omitempty"`      // 严格模式：所有节点都不隐式展开前置节点的输出，只使用配置和输入映射
+	Inputs      []ParamConfig    `json:"inputs,omitempty"`      // 调用方在执行时提供的输入参数
+	Outputs     []WorkflowOutput `json:"outputs,omitempty"`     // 声明的输出，未声明时以最后执行的节点输出作为最终输出
+}
+
+// WorkflowOutput 工作流输出声明
+type WorkflowOutput struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"` // 表达式，如 nodes.fetch.data.body.id
+	Description string `json:"description,omitempty"`
+}
+
+// WorkflowDefinition 持久化的工作流定义
+type WorkflowDefinition struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Workflow    Workflow `json:"workflow"`
+	Version     int      `json:"version"` // 当前版本号
+	CreatedAt   string   `json:"createdAt"`
+	UpdatedAt   string   `json:"updatedAt"`
+}
+
+// WorkflowVersion 工作流的不可变历史版本
+type WorkflowVersion struct {
+	WorkflowID   string   `json:"workflowId"`
+	Version      int      `json:"version"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Workflow     Workflow `json:"workflow"`
+	PromotedFrom int      `json:"promotedFrom,omitempty"` // 由哪个历史版本回滚而来
+	CreatedAt    string   `json:"createdAt"`
+}
+
+// WorkflowDiff 两个工作流版本之间的结构差异
+type WorkflowDiff struct {
+	FromVersion  int            `json:"fromVersion"`
+	ToVersion    int            `json:"toVersion"`
+	AddedNodes   []WorkflowNode `json:"addedNodes"`
+	RemovedNodes []WorkflowNode `json:"removedNodes"`
+	ChangedNodes []NodeChange   `json:"changedNodes"`
+	AddedEdges   []WorkflowEdge `json:"addedEdges"`
+	RemovedEdges []WorkflowEdge `json:"removedEdges"`
+	// ChangedSettings 工作流级配置的变更，如 timeout、maxParallel、strict、inputs、outputs
+	ChangedSettings []SettingChange `json:"changedSettings"`
+}
+
+// SettingChange 工作流级配置变更
+type SettingChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// NodeChange 节点变更
+type NodeChange struct {
+	NodeID string       `json:"nodeId"`
+	Fields []string     `json:"fields"` // 发生变化的字段，如 label、type、config
+	Before WorkflowNode `json:"before"`
+	After  WorkflowNode `json:"after"`
+}
+
+// SaveWorkflowRequest 创建/更新工作流请求
+type SaveWorkflowRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Workflow    Workflow `json:"workflow"`
+}
+
+// SubmitRunRequest 提交异步运行请求
+// 指定 workflowId 时执行已保存的工作流（version 为 0 表示当前版本），否则执行内联的 workflow
+type SubmitRunRequest struct {
+	WorkflowID string                 `json:"workflowId"`
+	Version    int                    `json:"version"`
+	Workflow   *Workflow              `json:"workflow"`
+	Inputs     map[string]interface{} `json:"inputs"`
+}
+
+// Schedule 定时触发器
+type Schedule struct {
+	ID           string                 `json:"id"`
+	WorkflowID   string                 `json:"workflowId"`
+	Version      int                    `json:"version,omitempty"`  // 固定执行的版本，0 表示每次执行当前版本
+	Cron         string                 `json:"cron"`               // 5 字段或 6 字段（含秒）cron 表达式
+	Timezone     string                 `json:"timezone,omitempty"` // IANA 时区，如 Asia/Shanghai，默认 UTC
+	Overlap      string                 `json:"overlap,omitempty"`  // 上一次运行未结束时的策略: skip（默认）、queue、allow
+	Inputs       map[string]interface{} `json:"inputs,omitempty"`   // 每次触发时提供的工作流输入
+	Enabled      bool                   `json:"enabled"`
+	LastRunID    string                 `json:"lastRunId,omitempty"`
+	LastFireTime string                 `json:"lastFireTime,omitempty"`
+	NextFireTime string                 `json:"nextFireTime,omitempty"`
+	CreatedAt    string                 `json:"createdAt"`
+	UpdatedAt    string                 `json:"updatedAt"`
+}
+
+// SaveScheduleRequest 创建/更新定时触发器请求
+type SaveScheduleRequest struct {
+	WorkflowID string                 `json:"workflowId" binding:"required"`
+	Version    int                    `json:"version"`
+	Cron       string                 `json:"cron" binding:"required"`
+	Timezone   string                 `json:"timezone"`
+	Overlap    string                 `json:"overlap"`
+	Inputs     map[string]interface{} `json:"inputs"`
+	Enabled    *bool                  `json:"enabled"` // 默认启用
+}
+
+// Webhook 入站 webhook 触发器
+// 外部系统向 /api/hooks/:token 发送请求即可启动工作流，请求内容作为起始节点的 $trigger 输入，
+// JSON 请求体的字段同时作为工作流输入
+type Webhook struct {
+	ID         string `json:"id"`
+	Token      string `json:"token"` // 触发地址中的随机令牌
+	WorkflowID string `json:"workflowId"`
+	Version    int    `json:"version,omitempty"`  // 固定执行的版本，0 表示每次执行当前版本
+	Secret     string `json:"-"`                  // 设置后要求请求携带 HMAC-SHA256 签名，不在响应中返回
+	HasSecret  bool   `json:"hasSecret"`          // 是否设置了签名密钥
+	Response   string `json:"response,omitempty"` // 响应方式: async（默认，立即返回运行 ID）、sync（等待运行结束返回最终输出）
+	Enabled    bool   `json:"enabled"`
+	CreatedAt  string `json:"createdAt"`
+	UpdatedAt  string `json:"updatedAt"`
+}
+
+// SaveWebhookRequest 创建/更新 webhook 触发器请求
+type SaveWebhookRequest struct {
+	WorkflowID string  `json:"workflowId" binding:"required"`
+	Version    int     `json:"version"`
+	Secret     *string `json:"secret"` // 未提供时保留原密钥，空字符串表示清除
+	Response   string  `json:"response"`
+	Enabled    *bool   `json:"enabled"` // 默认启用
 }
 
 // ExecuteWorkflowRequest 执行工作流请求
 type ExecuteWorkflowRequest struct {
+	Workflow Workflow               `json:"workflow"`
+	Inputs   map[string]interface{} `json:"inputs"`
+}
+
+// ValidateWorkflowRequest 校验工作流请求
+type ValidateWorkflowRequest struct {
 	Workflow Workflow `json:"workflow"`
 }
 
+// ExecuteStoredWorkflowRequest 执行已保存工作流请求（请求体可省略）
+type ExecuteStoredWorkflowRequest struct {
+	Inputs map[string]interface{} `json:"inputs"`
+}
+
 // NodeExecutionLog 节点执行日志
 type NodeExecutionLog struct {
 	NodeID    string      `json:"nodeId"`
 	NodeName  string      `json:"nodeName"`
-	Status    string      `json:"status"` // pending, running, success, error
+	Status    string      `json:"status"` // pending, running, retrying, success, error, skipped, cancelled, timeout
 	Message   string      `json:"message"`
 	Input     TaskInput   `json:"input,omitempty"`
 	Output    *TaskOutput `json:"output,omitempty"`
 	Duration  int64       `json:"duration,omitempty"` // 毫秒
+	Attempt   int         `json:"attempt,omitempty"`  // 重试日志对应的尝试序号
+	Attempts  int         `json:"attempts,omitempty"` // 完成日志记录的总尝试次数（配置重试时）
 	Timestamp string      `json:"timestamp"`
+
+	Compensation bool   `json:"compensation,omitempty"` // 本条日志属于节点的补偿操作
+	LoopID       string `json:"loopId,omitempty"`       // 本条日志属于该循环节点的循环体
+	Iteration    *int   `json:"iteration,omitempty"`    // 循环体日志对应的迭代序号（从 0 开始）
+
+	Children []NodeExecutionLog `json:"children,omitempty"` // 子工作流节点的完成日志中嵌套的子工作流节点日志
 }
 
 // WorkflowExecutionResult 工作流执行结果
 type WorkflowExecutionResult struct {
-	Status      string             `json:"status"` // success, error, cancelled
+	RunID       string             `json:"runId,omitempty"`
+	Status      string             `json:"status"` // queued, running, success, partial, error, cancelled, timeout
 	StartTime   string             `json:"startTime"`
 	EndTime     string             `json:"endTime"`
 	Logs        []NodeExecutionLog `json:"logs"`
 	FinalOutput *TaskOutput        `json:"finalOutput,omitempty"`
 	Error       string             `json:"error,omitempty"`
+	WorkflowID  string             `json:"workflowId,omitempty"` // 执行已保存的工作流时记录其 ID
+	Version     int                `json:"version,omitempty"`    // 执行的工作流版本
+	Inputs      TaskInput          `json:"inputs,omitempty"`     // 填充默认值后的工作流输入
+
+	FailedNodes      []string          `json:"failedNodes,omitempty"`      // 按 onError 策略失败后继续运行的节点，非空时状态为 partial
+	ValidationErrors []ValidationError `json:"validationErrors,omitempty"` // 未通过执行前校验时的问题列表
+}
+
+// ValidationError 工作流校验问题
+type ValidationError struct {
+	Code    string        `json:"code"`             // 问题类型，如 missing_param、dangling_edge、cycle
+	NodeID  string        `json:"nodeId,omitempty"` // 问题所在的节点
+	Field   string        `json:"field,omitempty"`  // 问题所在的参数、输入或输出名
+	Edge    *WorkflowEdge `json:"edge,omitempty"`   // 问题所在的边
+	Path    []NodeRef     `json:"path,omitempty"`   // 循环依赖经过的节点，首尾为同一节点
+	Message string        `json:"message"`
+}
+
+// NodeRef 节点引用
+type NodeRef struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// ValidationResult 工作流校验结果
+type ValidationResult struct {
+	Valid  bool              `json:"valid"`
+	Errors []ValidationError `json:"errors"`
+}
+
+// RunEvent 运行事件，ID 在同一运行内从 1 开始单调递增，用作 SSE 的 id 字段
+type RunEvent struct {
+	ID    int64       `json:"id"`
+	Event string      `json:"event"` // node_start, node_retry, node_iteration, node_complete, compensation_start, compensation_complete, complete
+	Data  interface{} `json:"data"`  // 节点日志或最终执行结果
+}
+
+// RunSummary 运行记录摘要（不含节点日志）
+type RunSummary struct {
+	RunID      string `json:"runId"`
+	WorkflowID string `json:"workflowId,omitempty"`
+	Version    int    `json:"version,omitempty"`
+	Status     string `json:"status"`
+	StartTime  string `json:"startTime"`
+	EndTime    string `json:"endTime,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Summary 获取运行记录摘要
+func (r WorkflowExecutionResult) Summary() RunSummary {
+	return RunSummary{
+		RunID:      r.RunID,
+		WorkflowID: r.WorkflowID,
+		Version:    r.Version,
+		Status:     r.Status,
+		StartTime:  r.StartTime,
+		EndTime:    r.EndTime,
+		Error:      r.Error,
+	}
 }
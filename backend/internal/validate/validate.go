@@ -0,0 +1,533 @@
+// Package validate 在执行前检查工作流定义
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"workflow-engine/internal/executor"
+	"workflow-engine/internal/expr"
+	"workflow-engine/internal/params"
+	"workflow-engine/internal/types"
+)
+
+// 校验问题类型
+const (
+	CodeInvalidNode         = "invalid_node"
+	CodeDuplicateNode       = "duplicate_node"
+	CodeUnknownTaskType     = "unknown_task_type"
+	CodeMissingParam        = "missing_param"
+	CodeInvalidParam        = "invalid_param"
+	CodeInvalidExpression   = "invalid_expression"
+	CodeDanglingEdge        = "dangling_edge"
+	CodeInvalidHandle       = "invalid_handle"
+	CodeInvalidOnError      = "invalid_on_error"
+	CodeInvalidLoop         = "invalid_loop"
+	CodeInvalidMerge        = "invalid_merge"
+	CodeInvalidCompensation = "invalid_compensation"
+	CodeCycle               = "cycle"
+	CodeInvalidInput        = "invalid_input"
+	CodeInvalidOutput       = "invalid_output"
+)
+
+// Workflow 检查工作流定义，返回所有问题（按节点、边、工作流输入输出的顺序）
+// triggered 表示运行带有触发数据，此时起始节点缺失的必填参数可能由触发数据的 body 隐式提供
+func Workflow(workflow types.Workflow, triggered bool) []types.ValidationError {
+	v := &validator{workflow: workflow, triggered: triggered, nodes: make(map[string]types.WorkflowNode)}
+	v.checkNodes()
+	v.checkEdges()
+	v.checkCycles()
+	v.checkInputs()
+	v.checkOutputs()
+	return v.errors
+}
+
+// Result 将问题列表包装为校验结果
+func Result(errors []types.ValidationError) types.ValidationResult {
+	if errors == nil {
+		errors = []types.ValidationError{}
+	}
+	return types.ValidationResult{Valid: len(errors) == 0, Errors: errors}
+}
+
+// Summary 概括问题列表，用于错误信息
+func Summary(errors []types.ValidationError) string {
+	if len(errors) == 1 {
+		return errors[0].Message
+	}
+	return fmt.Sprintf("%s 等 %d 个问题", errors[0].Message, len(errors))
+}
+
+type validator struct {
+	workflow  types.Workflow
+	triggered bool
+	nodes     map[string]types.WorkflowNode
+	errors    []types.ValidationError
+}
+
+func (v *validator) add(e types.ValidationError) {
+	v.errors = append(v.errors, e)
+}
+
+// checkNodes 检查节点 ID、任务类型和参数
+func (v *validator) checkNodes() {
+	predecessors := make(map[string]int)
+	errorRoutes := make(map[string]int)
+	for _, edge := range v.workflow.Edges {
+		predecessors[edge.Target]++
+		if edge.SourceHandle == executor.ErrorHandle {
+			errorRoutes[edge.Source]++
+		}
+	}
+
+	for _, node := range v.workflow.Nodes {
+		if node.ID == "" {
+			v.add(types.ValidationError{Code: CodeInvalidNode, Message: fmt.Sprintf("节点 %q 缺少 ID", node.Label)})
+			continue
+		}
+		if _, exists := v.nodes[node.ID]; exists {
+			v.add(types.ValidationError{Code: CodeDuplicateNode, NodeID: node.ID, Message: "节点 ID 重复: " + node.ID})
+			continue
+		}
+		v.nodes[node.ID] = node
+
+		switch node.OnError {
+		case "", types.OnErrorFail, types.OnErrorContinue:
+		case types.OnErrorRoute:
+			if errorRoutes[node.ID] == 0 {
+				v.add(types.ValidationError{Code: CodeInvalidOnError, NodeID: node.ID, Field: "onError", Message: fmt.Sprintf("节点 %q 的 onError 为 route，但没有连接错误分支（sourceHandle 为 error）的边", node.Label)})
+			}
+		default:
+			v.add(types.ValidationError{Code: CodeInvalidOnError, NodeID: node.ID, Field: "onError", Message: fmt.Sprintf("节点 %q 的 onError 无效: %s（可选 fail、continue、route）", node.Label, node.OnError)})
+		}
+
+		if node.Compensation != nil {
+			v.checkCompensation(node)
+		}
+		switch node.Type {
+		case executor.ForEachType:
+			v.checkLoop(node)
+		case executor.MergeType:
+			v.checkMerge(node, predecessors[node.ID])
+		case executor.SwitchType:
+			v.checkSwitch(node)
+		}
+
+		config, ok := executor.GetConfig(node.Type)
+		if !ok {
+			v.add(types.ValidationError{Code: CodeUnknownTaskType, NodeID: node.ID, Message: fmt.Sprintf("节点 %q 的任务类型未知: %s", node.Label, node.Type)})
+			continue
+		}
+
+		// 未开启严格模式且未声明输入映射时，缺失的参数可能由唯一前置节点的输出（或触发数据）隐式提供
+		merged := !v.workflow.Strict && len(node.InputMapping) == 0 &&
+			(predecessors[node.ID] == 1 || predecessors[node.ID] == 0 && v.triggered)
+		v.checkParams(nodeParams(node), config.Params, merged)
+		if node.Type == executor.IfConditionType {
+			v.checkCondition(node, merged)
+		}
+	}
+}
+
+// paramSet 待检查的一组参数：节点本身的配置或其补偿操作的配置
+type paramSet struct {
+	nodeID  string
+	subject string // 错误信息中的主语，如 节点 "请求"
+	prefix  string // 问题字段名的前缀，如 compensation.
+	config  types.TaskInput
+	mapping map[string]string
+}
+
+// nodeParams 节点本身的参数
+func nodeParams(node types.WorkflowNode) paramSet {
+	return paramSet{nodeID: node.ID, subject: fmt.Sprintf("节点 %q", node.Label), config: node.Config, mapping: node.InputMapping}
+}
+
+// checkParams 检查参数：必填、类型、可选项和表达式语法
+func (v *validator) checkParams(set paramSet, declared []types.ParamConfig, merged bool) {
+	for _, param := range declared {
+		field := set.prefix + param.Name
+		if source, mapped := set.mapping[param.Name]; mapped {
+			if err := expr.CheckExpression(source); err != nil {
+				v.add(types.ValidationError{Code: CodeInvalidExpression, NodeID: set.nodeID, Field: field, Message: fmt.Sprintf("%s 的输入映射 %s: %v", set.subject, param.Name, err)})
+			}
+			continue
+		}
+
+		value := set.config[param.Name]
+		if params.Missing(value) {
+			if param.Required && param.Default == nil && !merged {
+				v.add(types.ValidationError{Code: CodeMissingParam, NodeID: set.nodeID, Field: field, Message: fmt.Sprintf("%s 缺少必填参数: %s", set.subject, paramLabel(param))})
+			}
+			continue
+		}
+
+		// 含表达式的参数在运行时才能确定取值，只检查语法
+		if s, ok := value.(string); ok && expr.HasTemplate(s) {
+			continue
+		}
+		if _, err := params.Coerce(param, value); err != nil {
+			v.add(types.ValidationError{Code: CodeInvalidParam, NodeID: set.nodeID, Field: field, Message: fmt.Sprintf("%s 的参数 %s %v", set.subject, paramLabel(param), err)})
+		}
+	}
+
+	for _, name := range sortedKeys(set.config) {
+		if err := expr.Check(set.config[name]); err != nil {
+			v.add(types.ValidationError{Code: CodeInvalidExpression, NodeID: set.nodeID, Field: set.prefix + name, Message: fmt.Sprintf("%s 的参数 %s: %v", set.subject, name, err)})
+		}
+	}
+	for _, name := range sortedKeys(set.mapping) {
+		if !hasParam(declared, name) {
+			if err := expr.CheckExpression(set.mapping[name]); err != nil {
+				v.add(types.ValidationError{Code: CodeInvalidExpression, NodeID: set.nodeID, Field: set.prefix + name, Message: fmt.Sprintf("%s 的输入映射 %s: %v", set.subject, name, err)})
+			}
+		}
+	}
+}
+
+// checkCompensation 检查节点的补偿操作
+// 补偿操作单独执行，不能使用只能由引擎调度的循环、合并和子工作流任务类型
+func (v *validator) checkCompensation(node types.WorkflowNode) {
+	compensation := node.Compensation
+	config, ok := executor.GetConfig(compensation.Type)
+	if !ok {
+		v.add(types.ValidationError{Code: CodeUnknownTaskType, NodeID: node.ID, Field: "compensation", Message: fmt.Sprintf("节点 %q 的补偿操作任务类型未知: %s", node.Label, compensation.Type)})
+		return
+	}
+	switch compensation.Type {
+	case executor.ForEachType, executor.MergeType, executor.SubWorkflowType:
+		v.add(types.ValidationError{Code: CodeInvalidCompensation, NodeID: node.ID, Field: "compensation", Message: fmt.Sprintf("节点 %q 的补偿操作不能使用 %s 任务类型（只能在工作流中执行）", node.Label, compensation.Type)})
+		return
+	}
+	set := paramSet{
+		nodeID:  node.ID,
+		subject: fmt.Sprintf("节点 %q 补偿操作", node.Label),
+		prefix:  "compensation.",
+		config:  compensation.Config,
+	}
+	v.checkParams(set, config.Params, false)
+}
+
+// checkLoop 检查循环节点的循环体
+// 循环体作为独立的工作流校验（起始节点可由当前项隐式提供参数），问题记在循环节点上，字段以 body. 和循环体节点 ID 为前缀
+func (v *validator) checkLoop(node types.WorkflowNode) {
+	if node.Body == nil || len(node.Body.Nodes) == 0 {
+		v.add(types.ValidationError{Code: CodeInvalidLoop, NodeID: node.ID, Field: "body", Message: fmt.Sprintf("循环节点 %q 缺少循环体", node.Label)})
+		return
+	}
+
+	for _, problem := range Workflow(*node.Body, true) {
+		field := "body"
+		if problem.NodeID != "" {
+			field += "." + problem.NodeID
+		}
+		if problem.Field != "" {
+			field += "." + problem.Field
+		}
+		problem.NodeID, problem.Field = node.ID, field
+		problem.Message = fmt.Sprintf("循环节点 %q 的循环体: %s", node.Label, problem.Message)
+		v.add(problem)
+	}
+}
+
+// checkMerge 检查合并节点的入边数、等待方式和到达数量
+// 等待方式的取值由参数检查覆盖，参数检查跳过的表达式在这里报错；等待方式为 n-of-m 时到达数量需为不超过入边数的正整数
+func (v *validator) checkMerge(node types.WorkflowNode, incoming int) {
+	if incoming < 2 {
+		v.add(types.ValidationError{Code: CodeInvalidMerge, NodeID: node.ID, Message: fmt.Sprintf("合并节点 %q 至少需要两条入边，实际为 %d", node.Label, incoming)})
+		return
+	}
+	mode := node.Config["mode"]
+	if s, ok := mode.(string); ok && expr.HasTemplate(s) {
+		v.add(types.ValidationError{Code: CodeInvalidMerge, NodeID: node.ID, Field: "mode", Message: fmt.Sprintf("合并节点 %q 的等待方式需为固定值，不支持表达式", node.Label)})
+		return
+	}
+	if mode != executor.MergeNOfM {
+		return
+	}
+	if _, err := executor.MergeQuorum(node.Config, incoming); err != nil {
+		v.add(types.ValidationError{Code: CodeInvalidMerge, NodeID: node.ID, Field: "count", Message: fmt.Sprintf("合并节点 %q: %v", node.Label, err)})
+	}
+}
+
+// checkCondition 检查条件判断节点：未配置规则组时需要判断字段，配置了规则组时检查其结构（含表达式时在运行时检查）
+func (v *validator) checkCondition(node types.WorkflowNode, merged bool) {
+	rules := node.Config["rules"]
+	if params.Missing(rules) {
+		_, mapped := node.InputMapping["field"]
+		if params.Missing(node.Config["field"]) && !mapped && !merged {
+			v.add(types.ValidationError{Code: CodeMissingParam, NodeID: node.ID, Field: "field", Message: fmt.Sprintf("节点 %q 缺少必填参数: 判断字段（field），或配置规则组（rules）", node.Label)})
+		}
+		return
+	}
+	if s, ok := rules.(string); ok && expr.HasTemplate(s) {
+		return
+	}
+	if _, err := executor.ParseRule(rules); err != nil {
+		v.add(types.ValidationError{Code: CodeInvalidParam, NodeID: node.ID, Field: "rules", Message: fmt.Sprintf("节点 %q 的参数 规则组（rules） %v", node.Label, err)})
+	}
+}
+
+// checkSwitch 检查多分支判断节点的分支列表，缺失由参数检查覆盖，含表达式时在运行时检查
+func (v *validator) checkSwitch(node types.WorkflowNode) {
+	value := node.Config["cases"]
+	if params.Missing(value) {
+		return
+	}
+	if s, ok := value.(string); ok && expr.HasTemplate(s) {
+		return
+	}
+	if _, err := executor.SwitchCases(value); err != nil {
+		v.add(types.ValidationError{Code: CodeInvalidParam, NodeID: node.ID, Field: "cases", Message: fmt.Sprintf("节点 %q 的参数 分支（cases） %v", node.Label, err)})
+	}
+}
+
+// checkEdges 检查边的端点和分支
+func (v *validator) checkEdges() {
+	for i := range v.workflow.Edges {
+		edge := v.workflow.Edges[i]
+		source, sourceOK := v.nodes[edge.Source]
+		_, targetOK := v.nodes[edge.Target]
+		if !sourceOK || !targetOK {
+			// 另一端存在时记为该节点的问题，便于在画布上定位
+			var missing []string
+			nodeID := edge.Source
+			if !sourceOK {
+				missing = append(missing, edge.Source)
+				nodeID = ""
+				if targetOK {
+					nodeID = edge.Target
+				}
+			}
+			if !targetOK && edge.Target != edge.Source {
+				missing = append(missing, edge.Target)
+			}
+			v.add(types.ValidationError{Code: CodeDanglingEdge, NodeID: nodeID, Edge: &edge, Message: fmt.Sprintf("边 %s -> %s 引用了不存在的节点: %s", edge.Source, edge.Target, strings.Join(missing, ", "))})
+			continue
+		}
+
+		if edge.SourceHandle == "" {
+			continue
+		}
+		if edge.SourceHandle == executor.ErrorHandle {
+			if source.OnError != types.OnErrorRoute {
+				v.add(types.ValidationError{Code: CodeInvalidOnError, NodeID: source.ID, Edge: &edge, Message: fmt.Sprintf("节点 %q 连接了错误分支，但 onError 不是 route", source.Label)})
+			}
+			continue
+		}
+		if _, ok := executor.GetConfig(source.Type); !ok {
+			continue
+		}
+		if !containsString(executor.NodeHandles(source.Type, source.Config), edge.SourceHandle) {
+			v.add(types.ValidationError{Code: CodeInvalidHandle, NodeID: source.ID, Edge: &edge, Message: fmt.Sprintf("节点 %q 没有输出分支 %s", source.Label, edge.SourceHandle)})
+		}
+	}
+}
+
+// checkCycles 检查循环依赖，忽略端点不存在的边
+// 每个强连通分量报告一个问题，给出经过分量中第一个节点的最短环路
+func (v *validator) checkCycles() {
+	outgoing := make(map[string][]string)
+	for _, edge := range v.workflow.Edges {
+		if _, ok := v.nodes[edge.Source]; !ok {
+			continue
+		}
+		if _, ok := v.nodes[edge.Target]; !ok {
+			continue
+		}
+		outgoing[edge.Source] = append(outgoing[edge.Source], edge.Target)
+	}
+
+	for _, component := range v.components(outgoing) {
+		path := shortestCycle(component, outgoing)
+		if path == nil {
+			continue
+		}
+
+		refs := make([]types.NodeRef, len(path))
+		names := make([]string, len(path))
+		for i, id := range path {
+			refs[i] = types.NodeRef{ID: id, Label: v.nodes[id].Label}
+			names[i] = nodeName(v.nodes[id])
+		}
+		v.add(types.ValidationError{Code: CodeCycle, NodeID: path[0], Path: refs, Message: "工作流存在循环依赖: " + strings.Join(names, " -> ")})
+	}
+}
+
+// components 按节点声明顺序计算强连通分量（Tarjan 算法）
+// 分量内的节点同样按声明顺序排列
+func (v *validator) components(outgoing map[string][]string) [][]string {
+	order := make(map[string]int, len(v.nodes))
+	for i, node := range v.workflow.Nodes {
+		if _, ok := order[node.ID]; !ok {
+			order[node.ID] = i
+		}
+	}
+
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var result [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		low[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range outgoing[id] {
+			if _, seen := index[next]; !seen {
+				visit(next)
+				low[id] = min(low[id], low[next])
+			} else if onStack[next] {
+				low[id] = min(low[id], index[next])
+			}
+		}
+
+		if low[id] != index[id] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		sort.Slice(component, func(i, j int) bool { return order[component[i]] < order[component[j]] })
+		result = append(result, component)
+	}
+
+	for _, node := range v.workflow.Nodes {
+		if _, ok := v.nodes[node.ID]; !ok {
+			continue
+		}
+		if _, seen := index[node.ID]; !seen {
+			visit(node.ID)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return order[result[i][0]] < order[result[j][0]] })
+	return result
+}
+
+// shortestCycle 在强连通分量内查找从第一个节点出发并回到该节点的最短路径
+// 分量只有一个节点且没有自环时返回 nil
+func shortestCycle(component []string, outgoing map[string][]string) []string {
+	start := component[0]
+	inComponent := make(map[string]bool, len(component))
+	for _, id := range component {
+		inComponent[id] = true
+	}
+
+	parent := map[string]string{start: ""}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range outgoing[current] {
+			if next == start {
+				path := []string{start}
+				for id := current; id != start; id = parent[id] {
+					path = append(path, id)
+				}
+				// path 为逆序的 start <- ... <- current，翻转后补上回到起点的一步
+				for i, j := 1, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return append(path, start)
+			}
+			if _, seen := parent[next]; seen || !inComponent[next] {
+				continue
+			}
+			parent[next] = current
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// checkInputs 检查工作流输入声明
+func (v *validator) checkInputs() {
+	seen := make(map[string]bool)
+	for _, input := range v.workflow.Inputs {
+		switch {
+		case input.Name == "":
+			v.add(types.ValidationError{Code: CodeInvalidInput, Message: "工作流输入缺少名称"})
+		case seen[input.Name]:
+			v.add(types.ValidationError{Code: CodeInvalidInput, Field: input.Name, Message: "工作流输入重复: " + input.Name})
+		case input.Default != nil:
+			if _, err := params.Coerce(input, input.Default); err != nil {
+				v.add(types.ValidationError{Code: CodeInvalidInput, Field: input.Name, Message: fmt.Sprintf("工作流输入 %s 的默认值%v", input.Name, err)})
+			}
+		}
+		seen[input.Name] = true
+	}
+}
+
+// checkOutputs 检查工作流输出声明
+func (v *validator) checkOutputs() {
+	seen := make(map[string]bool)
+	for _, output := range v.workflow.Outputs {
+		switch {
+		case output.Name == "":
+			v.add(types.ValidationError{Code: CodeInvalidOutput, Message: "工作流输出缺少名称"})
+		case seen[output.Name]:
+			v.add(types.ValidationError{Code: CodeInvalidOutput, Field: output.Name, Message: "工作流输出重复: " + output.Name})
+		case strings.TrimSpace(output.Value) == "":
+			v.add(types.ValidationError{Code: CodeInvalidOutput, Field: output.Name, Message: "工作流输出缺少取值表达式: " + output.Name})
+		default:
+			if err := expr.CheckExpression(output.Value); err != nil {
+				v.add(types.ValidationError{Code: CodeInvalidExpression, Field: output.Name, Message: fmt.Sprintf("工作流输出 %s: %v", output.Name, err)})
+			}
+		}
+		seen[output.Name] = true
+	}
+}
+
+// nodeName 节点的显示名，用于错误信息
+func nodeName(node types.WorkflowNode) string {
+	if node.Label == "" || node.Label == node.ID {
+		return node.ID
+	}
+	return node.Label + "（" + node.ID + "）"
+}
+
+// paramLabel 参数的显示名
+func paramLabel(param types.ParamConfig) string {
+	if param.Label == "" || param.Label == param.Name {
+		return param.Name
+	}
+	return param.Label + "（" + param.Name + "）"
+}
+
+func hasParam(declared []types.ParamConfig, name string) bool {
+	for _, param := range declared {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,4 +1,4 @@
-import { TaskInput, TaskOutput } from "../types/workflow";
+import { TaskInput, TaskOutput, TaskParamConfig } from "../types/workflow";
 
 const API_BASE_URL =
   process.env.REACT_APP_API_URL || "http://localhost:8080/api";
@@ -9,6 +9,37 @@ export interface WorkflowNode {
   type: string;
   label: string;
   config: TaskInput;
+  retry?: RetryPolicy;
+  timeout?: number;
+  // 参数名 -> 上游字段表达式，如 { url: "nodes.fetch.data.body.next" }
+  inputMapping?: Record<string, string>;
+  // 同时就绪的节点中数值大的先执行，相同时按画布位置（先上后下、先左后右）和 ID 排序
+  priority?: number;
+  // 失败后的处理策略：fail（默认）结束运行，continue 跳过下游继续执行，route 沿 error 分支交给处理节点
+  onError?: "fail" | "continue" | "route";
+  // 运行失败时撤销本节点副作用的补偿操作，配置中可通过 original.data 访问原节点的输出
+  compensation?: Compensation;
+  // 循环节点（for-each）对每一项执行的子图
+  body?: Workflow;
+  position?: { x: number; y: number };
+}
+
+// 节点补偿操作
+export interface Compensation {
+  type: string;
+  config: TaskInput;
+  timeout?: number;
+}
+
+// 节点重试策略
+export interface RetryPolicy {
+  maxAttempts: number;
+  initialDelay?: number;
+  multiplier?: number;
+  maxDelay?: number;
+  jitter?: number;
+  retryOn?: string[];
+  retryOnStatus?: number[];
 }
 
 // 工作流边
@@ -16,34 +47,98 @@ export interface WorkflowEdge {
   id: string;
   source: string;
   target: string;
+  sourceHandle?: string;
 }
 
 // 工作流定义
 export interface Workflow {
   nodes: WorkflowNode[];
   edges: WorkflowEdge[];
+  maxParallel?: number;
+  timeout?: number;
+  strict?: boolean;
+  inputs?: TaskParamConfig[];
+  outputs?: WorkflowOutput[];
+}
+
+// 工作流输出声明
+export interface WorkflowOutput {
+  name: string;
+  value: string; // 表达式，如 nodes.fetch.data.body.id
+  description?: string;
+}
+
+// 已保存的工作流定义
+export interface WorkflowDefinition {
+  id: string;
+  name: string;
+  description?: string;
+  workflow: Workflow;
+  version: number;
+  createdAt: string;
+  updatedAt: string;
 }
 
 // 节点执行日志
 export interface NodeExecutionLog {
   nodeId: string;
   nodeName: string;
-  status: "pending" | "running" | "success" | "error" | "skipped";
+  status:
+    | "pending"
+    | "running"
+    | "retrying"
+    | "success"
+    | "error"
+    | "skipped"
+    | "cancelled"
+    | "timeout";
   message: string;
   input?: TaskInput;
   output?: TaskOutput;
   duration?: number;
+  // retrying 日志对应的尝试序号
+  attempt?: number;
+  // 完成日志记录的总尝试次数（配置重试时）
+  attempts?: number;
   timestamp: string;
+  // 本条日志属于节点的补偿操作
+  compensation?: boolean;
+  // 循环体中的节点日志所属的循环节点，以及迭代序号（从 0 开始）
+  loopId?: string;
+  iteration?: number;
+  // 子工作流节点的完成日志中嵌套的子工作流节点日志
+  children?: NodeExecutionLog[];
 }
 
 // 工作流执行结果
 export interface WorkflowExecutionResult {
-  status: "success" | "error";
+  runId?: string;
+  status: "success" | "partial" | "error" | "cancelled" | "timeout";
   startTime: string;
   endTime: string;
   logs: NodeExecutionLog[];
   finalOutput?: TaskOutput;
   error?: string;
+  workflowId?: string;
+  version?: number;
+  failedNodes?: string[];
+  validationErrors?: ValidationError[];
+}
+
+// 工作流校验问题
+export interface ValidationError {
+  code: string;
+  nodeId?: string;
+  field?: string;
+  edge?: WorkflowEdge;
+  path?: { id: string; label: string }[];
+  message: string;
+}
+
+// 工作流校验结果
+export interface ValidationResult {
+  valid: boolean;
+  errors: ValidationError[];
 }
 
 // 任务配置参数
@@ -64,6 +159,7 @@ export interface TaskConfig {
   category: string;
   description: string;
   params: TaskConfigParam[];
+  handles?: string[];
 }
 
 // 健康检查
@@ -97,14 +193,82 @@ export async function getTaskConfig(taskType: string): Promise<TaskConfig> {
   return response.json();
 }
 
+// 获取已保存的工作流列表
+export async function listWorkflows(): Promise<WorkflowDefinition[]> {
+  const response = await fetch(`${API_BASE_URL}/workflows`);
+  if (!response.ok) {
+    throw new Error("获取工作流列表失败");
+  }
+  const data = await response.json();
+  return data.workflows;
+}
+
+// 获取已保存的工作流
+export async function getWorkflow(id: string): Promise<WorkflowDefinition> {
+  const response = await fetch(`${API_BASE_URL}/workflows/${id}`);
+  if (!response.ok) {
+    throw new Error(`获取工作流失败: ${id}`);
+  }
+  return response.json();
+}
+
+// 保存工作流（未指定 id 时创建新工作流）
+export async function saveWorkflow(
+  definition: { name: string; description?: string; workflow: Workflow },
+  id?: string
+): Promise<WorkflowDefinition> {
+  const response = await fetch(
+    id ? `${API_BASE_URL}/workflows/${id}` : `${API_BASE_URL}/workflows`,
+    {
+      method: id ? "PUT" : "POST",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify(definition),
+    }
+  );
+  if (!response.ok) {
+    throw new Error("保存工作流失败");
+  }
+  return response.json();
+}
+
+// 删除工作流
+export async function deleteWorkflow(id: string): Promise<void> {
+  const response = await fetch(`${API_BASE_URL}/workflows/${id}`, {
+    method: "DELETE",
+  });
+  if (!response.ok) {
+    throw new Error(`删除工作流失败: ${id}`);
+  }
+}
+
+// 校验工作流
+export async function validateWorkflow(
+  workflow: Workflow
+): Promise<ValidationResult> {
+  const response = await fetch(`${API_BASE_URL}/workflow/validate`, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ workflow }),
+  });
+  if (!response.ok) {
+    throw new Error("校验工作流失败");
+  }
+  return response.json();
+}
+
 // 执行工作流（流式）
 export function executeWorkflowStream(
   workflow: Workflow,
   callbacks: {
     onNodeStart?: (log: NodeExecutionLog) => void;
     onNodeComplete?: (log: NodeExecutionLog) => void;
+    onCompensationComplete?: (log: NodeExecutionLog) => void;
     onComplete?: (result: WorkflowExecutionResult) => void;
-    onError?: (error: string) => void;
+    onError?: (error: string, validationErrors?: ValidationError[]) => void;
   }
 ): () => void {
   const controller = new AbortController();
@@ -117,9 +281,15 @@ export function executeWorkflowStream(
     body: JSON.stringify({ workflow }),
     signal: controller.signal,
   })
-    .then((response) => {
+    .then(async (response) => {
       if (!response.ok) {
-        throw new Error("执行工作流失败");
+        // 未通过执行前校验时返回 400 和问题列表
+        const body = await response.json().catch(() => null);
+        if (body?.errors) {
+          callbacks.onError?.(body.error, body.errors);
+          return;
+        }
+        throw new Error(body?.error || "执行工作流失败");
       }
 
       const reader = response.body?.getReader();
@@ -166,6 +336,11 @@ export function executeWorkflowStream(
                   case "node_complete":
                     callbacks.onNodeComplete?.(parsed as NodeExecutionLog);
                     break;
+                  case "compensation_complete":
+                    callbacks.onCompensationComplete?.(
+                      parsed as NodeExecutionLog
+                    );
+                    break;
                   case "complete":
                     callbacks.onComplete?.(parsed as WorkflowExecutionResult);
                     break;
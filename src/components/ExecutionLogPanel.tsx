@@ -7,6 +7,7 @@ import {
   CheckCircleOutlined,
   CloseCircleOutlined,
   MinusCircleOutlined,
+  ExclamationCircleOutlined,
 } from "@ant-design/icons";
 import {
   ExecutionLogEntry,
@@ -27,9 +28,12 @@ const getStatusTag = (status: NodeExecutionStatus) => {
   const config: Record<NodeExecutionStatus, { color: string; text: string }> = {
     pending: { color: "default", text: "等待" },
     running: { color: "processing", text: "执行中" },
+    retrying: { color: "warning", text: "重试中" },
     success: { color: "success", text: "成功" },
     error: { color: "error", text: "失败" },
     skipped: { color: "default", text: "跳过" },
+    cancelled: { color: "default", text: "已取消" },
+    timeout: { color: "error", text: "超时" },
   };
   const { color, text } = config[status] || config.pending;
   return <Tag color={color}>{text}</Tag>;
@@ -40,6 +44,7 @@ const getWorkflowStatusTag = (status: ExecutionStatus) => {
     idle: { color: "default", text: "等待执行" },
     running: { color: "processing", text: "执行中..." },
     success: { color: "success", text: "执行成功" },
+    partial: { color: "warning", text: "部分失败" },
     error: { color: "error", text: "执行失败" },
     cancelled: { color: "default", text: "已取消" },
     completed: { color: "success", text: "执行完成" },
@@ -174,6 +179,8 @@ const ExecutionLogPanel: React.FC<ExecutionLogPanelProps> = ({
             <SyncOutlined spin style={{ color: "#1890ff" }} />
           ) : status === "success" || status === "completed" ? (
             <CheckCircleOutlined style={{ color: "#52c41a" }} />
+          ) : status === "partial" ? (
+            <ExclamationCircleOutlined style={{ color: "#faad14" }} />
           ) : status === "error" ? (
             <CloseCircleOutlined style={{ color: "#ff4d4f" }} />
           ) : (
@@ -249,6 +256,22 @@ const ExecutionLogPanel: React.FC<ExecutionLogPanelProps> = ({
           </div>
         )}
 
+      {status === "partial" && (
+        <div
+          style={{
+            padding: "12px 16px",
+            borderTop: "1px solid #303030",
+            background: "rgba(250, 173, 20, 0.1)",
+          }}
+        >
+          <Text type="warning">
+            ⚠️ 工作流执行完成，
+            {mergedLogs.filter((l) => l.status === "error").length}{" "}
+            个任务失败，已按失败策略继续执行
+          </Text>
+        </div>
+      )}
+
       {status === "error" && (
         <div
           style={{
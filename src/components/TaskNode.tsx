@@ -5,10 +5,12 @@ import {
   SyncOutlined,
   CheckCircleOutlined,
   CloseCircleOutlined,
+  ClockCircleOutlined,
+  StopOutlined,
   DownOutlined,
   UpOutlined,
 } from "@ant-design/icons";
-import { TaskType } from "../types/workflow";
+import { TaskType, getNodeHandles } from "../types/workflow";
 import { NodeExecutionStatus } from "../engine/WorkflowExecutor";
 
 const { Text } = Typography;
@@ -38,9 +40,16 @@ const getStatusTag = (status: NodeExecutionStatus) => {
       text: "执行中",
       icon: <SyncOutlined spin />,
     },
+    retrying: {
+      color: "warning",
+      text: "重试中",
+      icon: <SyncOutlined spin />,
+    },
     success: { color: "success", text: "成功", icon: <CheckCircleOutlined /> },
     error: { color: "error", text: "失败", icon: <CloseCircleOutlined /> },
     skipped: { color: "default", text: "跳过", icon: null },
+    cancelled: { color: "default", text: "已取消", icon: <StopOutlined /> },
+    timeout: { color: "error", text: "超时", icon: <ClockCircleOutlined /> },
   };
   const { color, text, icon } = config[status] || config.pending;
   return (
@@ -99,7 +108,8 @@ const JsonPreview: React.FC<{ data: unknown; maxLength?: number }> = ({
 
 const TaskNode: React.FC<NodeProps> = ({ data, selected }) => {
   const nodeData = data as TaskNodeData;
-  const { taskType, label, executionLog } = nodeData;
+  const { taskType, label, config, executionLog } = nodeData;
+  const handles = getNodeHandles(taskType, config);
   const [expanded, setExpanded] = useState(true);
 
   // 是否有执行状态（包括 pending 和 running）
@@ -240,16 +250,35 @@ const TaskNode: React.FC<NodeProps> = ({ data, selected }) => {
         </div>
       )}
 
-      <Handle
-        type="source"
-        position={Position.Bottom}
-        style={{
-          background: taskType.color,
-          width: 10,
-          height: 10,
-          border: "2px solid #1f1f1f",
-        }}
-      />
+      {handles.length > 0 ? (
+        handles.map((handle, index) => (
+          <Handle
+            key={handle}
+            id={handle}
+            type="source"
+            position={Position.Bottom}
+            title={handle}
+            style={{
+              left: `${((index + 1) * 100) / (handles.length + 1)}%`,
+              background: taskType.color,
+              width: 10,
+              height: 10,
+              border: "2px solid #1f1f1f",
+            }}
+          />
+        ))
+      ) : (
+        <Handle
+          type="source"
+          position={Position.Bottom}
+          style={{
+            background: taskType.color,
+            width: 10,
+            height: 10,
+            border: "2px solid #1f1f1f",
+          }}
+        />
+      )}
     </div>
   );
 };
@@ -33,7 +33,7 @@ const TaskPanel: React.FC<TaskPanelProps> = ({ onDragStart }) => {
     return acc;
   }, {} as Record<TaskCategory, TaskType[]>);
 
-  const categories: TaskCategory[] = ["action", "condition"];
+  const categories: TaskCategory[] = ["action", "condition", "control"];
 
   if (loading) {
     return (
@@ -36,6 +36,7 @@ import {
   executeWorkflowStream,
   Workflow,
   NodeExecutionLog,
+  ValidationError,
 } from "../api/workflowApi";
 
 const { Text } = Typography;
@@ -152,6 +153,7 @@ const WorkflowCanvasInner: React.FC = () => {
       edges: edges.map((edge) => ({
         source: edge.source,
         target: edge.target,
+        sourceHandle: edge.sourceHandle ?? undefined,
       })),
     };
     const blob = new Blob([JSON.stringify(workflow, null, 2)], {
@@ -165,6 +167,39 @@ const WorkflowCanvasInner: React.FC = () => {
     URL.revokeObjectURL(url);
   }, [nodes, edges]);
 
+  // 标记未通过校验的节点（包括循环依赖经过的节点），其他节点清除执行状态
+  const markInvalidNodes = useCallback(
+    (validationErrors: ValidationError[]) => {
+      const problems = new Map<string, string[]>();
+      const addProblem = (nodeId: string, text: string) => {
+        problems.set(nodeId, [...(problems.get(nodeId) ?? []), text]);
+      };
+      for (const error of validationErrors) {
+        const nodeIds = new Set(error.path?.map((ref) => ref.id));
+        if (error.nodeId) {
+          nodeIds.add(error.nodeId);
+        }
+        nodeIds.forEach((nodeId) => addProblem(nodeId, error.message));
+      }
+
+      setNodes((nds) =>
+        nds.map((node) => ({
+          ...node,
+          data: {
+            ...node.data,
+            executionLog: problems.has(node.id)
+              ? {
+                  status: "error" as const,
+                  output: { error: problems.get(node.id)!.join("\n") },
+                }
+              : undefined,
+          },
+        }))
+      );
+    },
+    [setNodes]
+  );
+
   // 执行工作流
   const executeWorkflow = useCallback(() => {
     if (nodes.length === 0) {
@@ -192,6 +227,7 @@ const WorkflowCanvasInner: React.FC = () => {
           id: node.id,
           type: data.taskType.id,
           label: data.label,
+          position: node.position,
           config: data.config as TaskInput,
         };
       }),
@@ -199,12 +235,15 @@ const WorkflowCanvasInner: React.FC = () => {
         id: edge.id,
         source: edge.source,
         target: edge.target,
+        sourceHandle: edge.sourceHandle ?? undefined,
       })),
     };
 
     // 使用流式 API 执行工作流
     executeWorkflowStream(workflow, {
       onNodeStart: (log: NodeExecutionLog) => {
+        // 循环体中的节点不在画布上
+        if (log.loopId) return;
         // 节点开始执行，更新为 running 状态
         setNodes((nds) =>
           nds.map((node) =>
@@ -221,6 +260,7 @@ const WorkflowCanvasInner: React.FC = () => {
         );
       },
       onNodeComplete: (log: NodeExecutionLog) => {
+        if (log.loopId) return;
         // 节点执行完成，更新状态和日志
         setNodes((nds) =>
           nds.map((node) =>
@@ -230,7 +270,7 @@ const WorkflowCanvasInner: React.FC = () => {
                   data: {
                     ...node.data,
                     executionLog: {
-                      status: log.status as "success" | "error",
+                      status: log.status,
                       input: log.input,
                       output: log.output,
                       duration: log.duration,
@@ -241,20 +281,34 @@ const WorkflowCanvasInner: React.FC = () => {
           )
         );
       },
+      onCompensationComplete: (log: NodeExecutionLog) => {
+        if (log.status === "success") {
+          message.info(`已执行补偿操作: ${log.nodeName}`);
+        } else {
+          message.error(log.message);
+        }
+      },
       onComplete: (result) => {
-        setExecutionStatus(result.status === "success" ? "completed" : "error");
         if (result.status === "success") {
+          setExecutionStatus("completed");
           message.success("工作流执行成功");
+        } else if (result.status === "partial") {
+          setExecutionStatus("partial");
+          message.warning(`工作流部分完成: ${result.error}`);
         } else {
+          setExecutionStatus("error");
           message.error(`工作流执行失败: ${result.error}`);
         }
       },
-      onError: (errorMessage) => {
+      onError: (errorMessage, validationErrors) => {
         setExecutionStatus("error");
         message.error(`执行失败: ${errorMessage}`);
+        if (validationErrors) {
+          markInvalidNodes(validationErrors);
+        }
       },
     });
-  }, [nodes, edges, setNodes]);
+  }, [nodes, edges, setNodes, markInvalidNodes]);
 
   // 清除执行日志
   const clearExecutionLogs = useCallback(() => {
@@ -6,6 +6,7 @@ export type ExecutionStatus =
   | "idle"
   | "running"
   | "success"
+  | "partial"
   | "error"
   | "cancelled"
   | "completed";
@@ -14,9 +15,12 @@ export type ExecutionStatus =
 export type NodeExecutionStatus =
   | "pending"
   | "running"
+  | "retrying"
   | "success"
   | "error"
-  | "skipped";
+  | "skipped"
+  | "cancelled"
+  | "timeout";
 
 // 执行日志条目
 export interface ExecutionLogEntry {
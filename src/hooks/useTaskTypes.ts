@@ -37,6 +37,7 @@ const convertToTaskType = (config: TaskConfig): TaskType => {
       description: p.description,
       options: p.options,
     })),
+    handles: config.handles,
   };
 };
 
@@ -48,6 +49,7 @@ export function useTaskTypes(): UseTaskTypesResult {
   >({
     action: [],
     condition: [],
+    control: [],
   });
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
@@ -64,6 +66,7 @@ export function useTaskTypes(): UseTaskTypesResult {
       const grouped: Record<TaskCategory, TaskType[]> = {
         action: [],
         condition: [],
+        control: [],
       };
 
       // 遍历每个分类
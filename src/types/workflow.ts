@@ -44,13 +44,31 @@ export interface TaskType {
   category: TaskCategory;
   // 参数配置（从后端获取）
   params?: TaskParamConfig[];
+  // 输出分支（如 if-condition 的 true/false）
+  handles?: string[];
   // 任务对应的可执行脚本
   script?: TaskScript;
   // 默认执行器（内置任务）
   executor?: TaskExecutor;
 }
 
-export type TaskCategory = "action" | "condition";
+export type TaskCategory = "action" | "condition" | "control";
+
+// 节点的输出分支：switch 节点为各分支名称加 default 分支，其他任务类型为其声明的分支
+export const getNodeHandles = (
+  taskType: TaskType,
+  config?: Record<string, unknown>
+): string[] => {
+  const handles = taskType.handles ?? [];
+  const cases = config?.cases;
+  if (taskType.id !== "switch" || !Array.isArray(cases)) {
+    return handles;
+  }
+  const names = cases
+    .map((item) => (item as { name?: unknown } | null)?.name)
+    .filter((name): name is string => typeof name === "string" && name !== "");
+  return [...names, ...handles];
+};
 
 // 分类配置
 export interface CategoryConfig {
@@ -62,6 +80,7 @@ export interface CategoryConfig {
 export const CATEGORY_CONFIG: Record<TaskCategory, CategoryConfig> = {
   action: { icon: "⚡", color: "#2196F3" },
   condition: { icon: "❓", color: "#FF9800" },
+  control: { icon: "🔁", color: "#9C27B0" },
 };
 
 // 默认任务图标映射（根据任务 ID 前缀）
@@ -69,6 +88,10 @@ export const getTaskIcon = (taskId: string): string => {
   const iconMap: Record<string, string> = {
     http: "🌐",
     if: "❓",
+    "for-each": "🔁",
+    "sub-workflow": "🧩",
+    merge: "🔀",
+    switch: "🧭",
   };
 
   for (const [prefix, icon] of Object.entries(iconMap)) {
@@ -83,6 +106,7 @@ export const getTaskIcon = (taskId: string): string => {
 export const CATEGORY_NAMES: Record<TaskCategory, string> = {
   action: "操作",
   condition: "条件",
+  control: "流程控制",
 };
 
 // 创建成功的任务输出